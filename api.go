@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"time"
 
 	"github.com/tylermmorton/tmpl"
 )
@@ -82,6 +83,26 @@ type EventSource interface {
 	Subscribe(wr http.ResponseWriter, req *http.Request) error
 }
 
+// SubscribeHook is called before a client subscribes to the EventSource. The request
+// context is canceled once the subscription ends. Returning an error rejects the
+// subscription, and the error is passed to the ErrorBoundary.
+type SubscribeHook interface {
+	OnSubscribe(req *http.Request) error
+}
+
+// UnsubscribeHook is called after a subscription to the EventSource has ended,
+// either because the client disconnected or Subscribe returned.
+type UnsubscribeHook interface {
+	OnUnsubscribe(req *http.Request)
+}
+
+// SubscriberLimit limits the number of live subscribers of the EventSource. Clients
+// subscribing while the limit is reached receive a 503 Service Unavailable. A limit
+// of zero or less means there is no limit.
+type SubscriberLimit interface {
+	MaxSubscribers() int
+}
+
 // ErrorBoundary handles all errors returned by methods of the Controller API. Use
 // this to catch known errors and return http.HandlerFuncs to handle them. Typically,
 // this is used to redirect the user to an error page or display a message.
@@ -125,6 +146,18 @@ type LayoutProvider interface {
 	Layout() Handler
 }
 
+// OutletStreamer can be implemented by a Controller rendered within a layout's outlet. When
+// StreamOutlet returns true, everything the layout renders before the {{outlet}} is flushed to
+// the client without waiting for the Controller's Loader to finish. This lowers the time to
+// first byte of pages with slow Loaders.
+//
+// Note that once the layout has been flushed the response status and headers have been sent.
+// Errors, redirects and headers set by the Controller after its hooks have been executed can
+// no longer change them; the Controller's output is rendered within the outlet regardless.
+type OutletStreamer interface {
+	StreamOutlet(req *http.Request) bool
+}
+
 // RouterProvider is executed when the torque Controller is first initialized. Using
 // the given Router interface, one can register additional handlers, middleware, etc.
 //
@@ -142,6 +175,30 @@ type RouterProvider interface {
 	Router(r Router)
 }
 
+// MiddlewareProvider is executed when the Controller is first initialized. The returned
+// middlewares wrap the Controller, and if it is also a RouterProvider, every handler in
+// its router's subtree. The first middleware is the outermost.
+type MiddlewareProvider interface {
+	Middlewares() []Middleware
+}
+
+// NotFoundProvider is executed when the Controller is first initialized. The returned Handler
+// is served with a 404 status when a request path does not match any route in the Controller's
+// subtree. It is rendered within the outlet of the nearest layout, so the page keeps the look
+// of the rest of the application.
+//
+// When multiple Controllers along the request path implement NotFoundProvider, the one nearest
+// to the requested path is used.
+type NotFoundProvider interface {
+	NotFound() Handler
+}
+
+// MethodNotAllowedProvider is the same as NotFoundProvider, but its Handler is served with a
+// 405 status when the request path matches a route that doesn't accept the request method.
+type MethodNotAllowedProvider interface {
+	MethodNotAllowed() Handler
+}
+
 type GuardProvider interface {
 	Guards() []Guard
 }
@@ -153,19 +210,33 @@ type PluginProvider interface {
 	Plugins() []Plugin
 }
 
-// TODO(v2.1) Easily add a deadline to a request, exceeded deadlines get
-//   sent to the ErrorBoundary wrapped in a context.DeadlineExceeded error.
-//type DeadlineProvider interface {
-//	Deadline(req *http.Request) (deadline time.Time, ok bool)
-//}
+// DeadlineProvider is executed before the request is handled by the Controller. The returned
+// deadline is applied to the request context before any hooks, Loader or Action are executed.
+// Errors wrapping context.DeadlineExceeded are sent to the nearest DeadlineBoundary.
+//
+// Note that when rendering an outlet chain, the earliest deadline along the chain applies to
+// every Controller handled after it.
+type DeadlineProvider interface {
+	Deadline(req *http.Request) (deadline time.Time, ok bool)
+}
+
+// DeadlineBoundary handles errors wrapping context.DeadlineExceeded, such as those caused by
+// an exceeded DeadlineProvider deadline. Like ErrorBoundary, the returned http.HandlerFunc is
+// used to handle the request. If no DeadlineBoundary up the parent chain handles the error, it
+// is passed to the ErrorBoundary instead.
+type DeadlineBoundary interface {
+	DeadlineBoundary(wr http.ResponseWriter, req *http.Request) http.HandlerFunc
+}
 
-// TODO(v2.1) Context driven boundaries may be useful in some scenarios
-//type DeadlineBoundary interface {
-//	DeadlineBoundary(wr http.ResponseWriter, req *http.Request) http.HandlerFunc
-//}
-//type CancelBoundary interface {
-//	CancelBoundary(wr http.ResponseWriter, req *http.Request) http.HandlerFunc
-//}
+// CancelBoundary handles errors wrapping context.Canceled returned after the request context
+// was canceled, i.e. because the client navigated away before the request was handled. The
+// returned http.HandlerFunc is used to handle the request, for example to release resources.
+//
+// Canceled requests are never passed to the ErrorBoundary or PanicBoundary and are not logged
+// as errors, even if no CancelBoundary up the parent chain handles them.
+type CancelBoundary interface {
+	CancelBoundary(wr http.ResponseWriter, req *http.Request) http.HandlerFunc
+}
 
 func assertImplementations[T ViewModel](h *handlerImpl[T], ctl Controller, vm ViewModel) error {
 	var err error
@@ -205,6 +276,18 @@ func assertImplementations[T ViewModel](h *handlerImpl[T], ctl Controller, vm Vi
 		h.eventSource = eventSource
 	}
 
+	if subscribeHook, ok := ctl.(SubscribeHook); ok {
+		h.subscribeHook = subscribeHook
+	}
+
+	if unsubscribeHook, ok := ctl.(UnsubscribeHook); ok {
+		h.unsubscribeHook = unsubscribeHook
+	}
+
+	if subscriberLimit, ok := ctl.(SubscriberLimit); ok {
+		h.subscriberLimit = subscriberLimit
+	}
+
 	if errorBoundary, ok := ctl.(ErrorBoundary); ok {
 		h.errorBoundary = errorBoundary
 	}
@@ -213,6 +296,18 @@ func assertImplementations[T ViewModel](h *handlerImpl[T], ctl Controller, vm Vi
 		h.panicBoundary = panicBoundary
 	}
 
+	if deadlineProvider, ok := ctl.(DeadlineProvider); ok {
+		h.deadlineProvider = deadlineProvider
+	}
+
+	if deadlineBoundary, ok := ctl.(DeadlineBoundary); ok {
+		h.deadlineBoundary = deadlineBoundary
+	}
+
+	if cancelBoundary, ok := ctl.(CancelBoundary); ok {
+		h.cancelBoundary = cancelBoundary
+	}
+
 	if hookProvider, ok := ctl.(HookProvider); ok {
 		h.hookProvider = hookProvider
 	}
@@ -225,8 +320,37 @@ func assertImplementations[T ViewModel](h *handlerImpl[T], ctl Controller, vm Vi
 		h.setParent(layoutHandler)
 	}
 
+	if outletStreamer, ok := ctl.(OutletStreamer); ok {
+		h.outletStreamer = outletStreamer
+	}
+
+	// NotFound and MethodNotAllowed handlers are rendered within the nearest
+	// outlet and must be known before the router is created. Providers returning
+	// nil fall back to the default handlers.
+	if notFoundProvider, ok := ctl.(NotFoundProvider); ok {
+		h.notFound = notFoundProvider.NotFound()
+		if outlet := nearestOutlet(h); outlet != nil && h.notFound != nil {
+			rootHandler(h.notFound).setParent(outlet)
+		}
+	}
+
+	if methodNotAllowedProvider, ok := ctl.(MethodNotAllowedProvider); ok {
+		h.methodNotAllowed = methodNotAllowedProvider.MethodNotAllowed()
+		if outlet := nearestOutlet(h); outlet != nil && h.methodNotAllowed != nil {
+			rootHandler(h.methodNotAllowed).setParent(outlet)
+		}
+	}
+
+	// middlewares must be known before the router is created
+	if middlewareProvider, ok := ctl.(MiddlewareProvider); ok {
+		h.middlewares = append(h.middlewares, middlewareProvider.Middlewares()...)
+	}
+
 	if routerProvider, ok := ctl.(RouterProvider); ok {
-		h.router = createRouter[T](h, routerProvider.Router)
+		h.router, err = createRouter[T](h, routerProvider.Router)
+		if err != nil {
+			return err
+		}
 	}
 
 	if guardProvider, ok := ctl.(GuardProvider); ok {
@@ -0,0 +1,108 @@
+package torque_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockCancelBoundary struct {
+	CancelBoundaryFunc func(wr http.ResponseWriter, req *http.Request) http.HandlerFunc
+}
+
+func (m MockCancelBoundary) CancelBoundary(wr http.ResponseWriter, req *http.Request) http.HandlerFunc {
+	return m.CancelBoundaryFunc(wr, req)
+}
+
+// mockCanceledLoader returns an error wrapping context.Canceled, canceling the request context
+// first if cancel is not nil
+func mockCanceledLoader(cancel context.CancelFunc) MockLoader[MockTemplateProvider] {
+	return MockLoader[MockTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+			if cancel != nil {
+				cancel()
+			}
+			return MockTemplateProvider{}, fmt.Errorf("failed to load: %w", context.Canceled)
+		},
+	}
+}
+
+var mockFailingErrorBoundary = MockErrorBoundary{
+	ErrorBoundaryFunc: func(wr http.ResponseWriter, req *http.Request, err error) http.HandlerFunc {
+		return func(wr http.ResponseWriter, req *http.Request) {
+			http.Error(wr, err.Error(), http.StatusInternalServerError)
+		}
+	},
+}
+
+func TestCancel_CancelBoundary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var handled = false
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockCancelBoundary
+		MockErrorBoundary
+	}{
+		MockLoader: mockCanceledLoader(cancel),
+		MockCancelBoundary: MockCancelBoundary{
+			CancelBoundaryFunc: func(wr http.ResponseWriter, req *http.Request) http.HandlerFunc {
+				return func(wr http.ResponseWriter, req *http.Request) {
+					handled = true
+				}
+			},
+		},
+		MockErrorBoundary: mockFailingErrorBoundary,
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	Expect(handled).To(BeTrue())
+	Expect(wr.Body.String()).To(BeEmpty())
+}
+
+func TestCancel_NoCancelBoundary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockErrorBoundary
+	}{
+		MockLoader:        mockCanceledLoader(cancel),
+		MockErrorBoundary: mockFailingErrorBoundary,
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	// the error page is not rendered for canceled requests
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(BeEmpty())
+}
+
+func TestCancel_RequestNotCanceled(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockErrorBoundary
+	}{
+		MockLoader:        mockCanceledLoader(nil),
+		MockErrorBoundary: mockFailingErrorBoundary,
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// context.Canceled errors unrelated to the request are regular errors
+	Expect(wr.Code).To(Equal(http.StatusInternalServerError))
+	Expect(wr.Body.String()).To(Equal("failed to load: context canceled\n"))
+}
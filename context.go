@@ -20,10 +20,19 @@ const (
 	scriptsKey      contextKey = "scripts"
 	funcMapKey      contextKey = "funcMap"
 	renderTargetKey contextKey = "renderTarget"
+	shutdownKey     contextKey = "shutdown"
+	requestIDKey    contextKey = "requestID"
 
 	// internal keys
-	paramsContextKey      contextKey = "params"
-	routerMatchContextKey contextKey = "outlet-flow"
+	paramsContextKey          contextKey = "params"
+	routerContextKey          contextKey = "router"
+	outletContextKey          contextKey = "outlet"
+	deferredContextKey        contextKey = "deferred"
+	patternContextKey         contextKey = "pattern"
+	optionsContextKey         contextKey = "options"
+	metricsRecorderContextKey contextKey = "metricsRecorder"
+	formStateContextKey       contextKey = "formState"
+	routerMatchContextKey     contextKey = "outlet-flow"
 )
 
 type Mode string
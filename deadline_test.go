@@ -0,0 +1,160 @@
+package torque_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockDeadlineProvider struct {
+	DeadlineFunc func(req *http.Request) (time.Time, bool)
+}
+
+func (m MockDeadlineProvider) Deadline(req *http.Request) (time.Time, bool) {
+	return m.DeadlineFunc(req)
+}
+
+type MockDeadlineBoundary struct {
+	DeadlineBoundaryFunc func(wr http.ResponseWriter, req *http.Request) http.HandlerFunc
+}
+
+func (m MockDeadlineBoundary) DeadlineBoundary(wr http.ResponseWriter, req *http.Request) http.HandlerFunc {
+	return m.DeadlineBoundaryFunc(wr, req)
+}
+
+var (
+	mockDeadline = MockDeadlineProvider{
+		DeadlineFunc: func(req *http.Request) (time.Time, bool) {
+			return time.Now().Add(10 * time.Millisecond), true
+		},
+	}
+
+	mockDeadlineBoundary = MockDeadlineBoundary{
+		DeadlineBoundaryFunc: func(wr http.ResponseWriter, req *http.Request) http.HandlerFunc {
+			return func(wr http.ResponseWriter, req *http.Request) {
+				http.Error(wr, "too slow", http.StatusGatewayTimeout)
+			}
+		},
+	}
+
+	// mockSlowLoader waits for the request context to be done
+	mockSlowLoader = MockLoader[MockTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+			select {
+			case <-req.Context().Done():
+				return MockTemplateProvider{}, fmt.Errorf("failed to load: %w", req.Context().Err())
+			case <-time.After(time.Second):
+				return MockTemplateProvider{Message: "no deadline"}, nil
+			}
+		},
+	}
+)
+
+func TestDeadline_DeadlineBoundary(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockDeadlineProvider
+		MockDeadlineBoundary
+	}{
+		MockLoader:           mockSlowLoader,
+		MockDeadlineProvider: mockDeadline,
+		MockDeadlineBoundary: mockDeadlineBoundary,
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Expect(wr.Code).To(Equal(http.StatusGatewayTimeout))
+	Expect(wr.Body.String()).To(Equal("too slow\n"))
+}
+
+func TestDeadline_ErrorBoundaryFallback(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockDeadlineProvider
+		MockErrorBoundary
+	}{
+		MockLoader:           mockSlowLoader,
+		MockDeadlineProvider: mockDeadline,
+		MockErrorBoundary: MockErrorBoundary{
+			ErrorBoundaryFunc: func(wr http.ResponseWriter, req *http.Request, err error) http.HandlerFunc {
+				return func(wr http.ResponseWriter, req *http.Request) {
+					http.Error(wr, err.Error(), http.StatusInternalServerError)
+				}
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Expect(wr.Code).To(Equal(http.StatusInternalServerError))
+	Expect(wr.Body.String()).To(Equal("failed to load: context deadline exceeded\n"))
+}
+
+func TestDeadline_ParentDeadlineBoundary(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockDeadlineProvider
+		MockLayoutProvider
+	}{
+		MockLoader:           mockSlowLoader,
+		MockDeadlineProvider: mockDeadline,
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return torque.MustNew[MockDivOutletTemplateProvider](&struct {
+					MockLoader[MockDivOutletTemplateProvider]
+					MockDeadlineBoundary
+				}{
+					MockLoader: MockLoader[MockDivOutletTemplateProvider]{
+						LoadFunc: func(req *http.Request) (MockDivOutletTemplateProvider, error) {
+							return MockDivOutletTemplateProvider{}, nil
+						},
+					},
+					MockDeadlineBoundary: mockDeadlineBoundary,
+				})
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Expect(wr.Code).To(Equal(http.StatusGatewayTimeout))
+	Expect(wr.Body.String()).To(Equal("too slow\n"))
+}
+
+func TestDeadline_NoDeadline(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockDeadlineProvider
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				_, ok := req.Context().Deadline()
+				return MockTemplateProvider{Message: fmt.Sprint(ok)}, nil
+			},
+		},
+		MockDeadlineProvider: MockDeadlineProvider{
+			DeadlineFunc: func(req *http.Request) (time.Time, bool) {
+				return time.Time{}, false
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(Equal("false"))
+}
@@ -0,0 +1,234 @@
+package torque
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/tylermmorton/tmpl"
+)
+
+// Deferred is a value resolved in the background while the page is rendered. A Loader can
+// return it as part of its ViewModel so slow data doesn't block the rest of the page.
+//
+// When rendered using the {{ deferred }} template func, a fallback is rendered in its place.
+// The resolved value is rendered using its own template and streamed to the client later in
+// the same response, replacing the fallback.
+//
+//	{{ deferred .Comments "Loading comments..." }}
+type Deferred[T tmpl.TemplateProvider] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// Defer calls fn in a new goroutine and returns a Deferred resolving to its result.
+func Defer[T tmpl.TemplateProvider](fn func() (T, error)) *Deferred[T] {
+	var d = &Deferred[T]{done: make(chan struct{})}
+	go func() {
+		defer close(d.done)
+		defer func() {
+			if p := recover(); p != nil {
+				d.err = fmt.Errorf("deferred panic: %v", p)
+			}
+		}()
+		d.val, d.err = fn()
+	}()
+	return d
+}
+
+// Await blocks until the Deferred is resolved and returns its result.
+func (d *Deferred[T]) Await() (T, error) {
+	<-d.done
+	return d.val, d.err
+}
+
+// MarshalJSON awaits the Deferred and encodes its resolved value.
+func (d *Deferred[T]) MarshalJSON() ([]byte, error) {
+	val, err := d.Await()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(val)
+}
+
+func (d *Deferred[T]) resolved() <-chan struct{} {
+	return d.done
+}
+
+func (d *Deferred[T]) render(req *http.Request) (template.HTML, error) {
+	val, err := d.Await()
+	if err != nil {
+		return "", err
+	}
+
+	renderer, err := deferredRenderer[T](val)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = renderer.template.Render(&buf, val, tmpl.WithFuncs(tmpl.FuncMap{
+		outletIdent:   outletFunc(nil),
+		urlForIdent:   urlForFunc(req),
+		deferredIdent: deferredFunc(nil),
+	}))
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// deferredValue is the type-erased interface of Deferred used by the template func.
+type deferredValue interface {
+	resolved() <-chan struct{}
+	render(req *http.Request) (template.HTML, error)
+}
+
+// deferredRenderers caches the compiled templates of deferred values by type
+var deferredRenderers sync.Map
+
+func deferredRenderer[T tmpl.TemplateProvider](tp T) (*templateRenderer[T], error) {
+	var typ = reflect.TypeOf(tp)
+	if r, ok := deferredRenderers.Load(typ); ok {
+		return r.(*templateRenderer[T]), nil
+	}
+
+	r, _, err := createTemplateRenderer[T](tp)
+	if err != nil {
+		return nil, err
+	}
+	deferredRenderers.Store(typ, r)
+	return r, nil
+}
+
+// deferredQueue collects the Deferred values rendered during a request,
+// so they can be streamed once the page has been written.
+type deferredQueue struct {
+	mu      sync.Mutex
+	logger  *slog.Logger
+	entries []deferredEntry
+}
+
+type deferredEntry struct {
+	id    string
+	value deferredValue
+}
+
+func (q *deferredQueue) push(value deferredValue) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var id = fmt.Sprintf("torque-deferred-%d", len(q.entries))
+	q.entries = append(q.entries, deferredEntry{id: id, value: value})
+	return id
+}
+
+// withDeferredQueue attaches a deferredQueue to the request, unless one is already attached
+// by a handler further up the stack. The returned func streams the queued values and is a
+// no-op for every handler but the one that attached the queue.
+func withDeferredQueue(req *http.Request, logger *slog.Logger) (*http.Request, func(wr http.ResponseWriter, req *http.Request)) {
+	if _, ok := req.Context().Value(deferredContextKey).(*deferredQueue); ok {
+		return req, func(http.ResponseWriter, *http.Request) {}
+	}
+
+	var q = &deferredQueue{logger: logger}
+	return req.WithContext(context.WithValue(req.Context(), deferredContextKey, q)), q.stream
+}
+
+// deferredScript swaps the fallback of a Deferred value with its resolved content.
+const deferredScript = `<script>function $torqueResolve(id){` +
+	`var p=document.getElementById(id),t=document.getElementById(id+"-content");` +
+	`if(p&&t){p.replaceWith(t.content);}if(t){t.remove();}}</script>`
+
+// stream writes the resolved content of the queued values to wr in the order they resolve.
+func (q *deferredQueue) stream(wr http.ResponseWriter, req *http.Request) {
+	q.mu.Lock()
+	var entries = q.entries
+	q.mu.Unlock()
+	if len(entries) == 0 {
+		return
+	}
+
+	var (
+		flusher, _ = wr.(http.Flusher)
+		resolved   = make(chan deferredEntry, len(entries))
+	)
+	for _, entry := range entries {
+		go func(entry deferredEntry) {
+			<-entry.value.resolved()
+			resolved <- entry
+		}(entry)
+	}
+
+	if _, err := wr.Write([]byte(deferredScript)); err != nil {
+		return
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for range entries {
+		var entry deferredEntry
+		select {
+		case entry = <-resolved:
+		case <-req.Context().Done():
+			return
+		}
+
+		content, err := entry.value.render(req)
+		if err != nil {
+			// the fallback is removed, the page can't indicate the error anymore
+			q.logger.LogAttrs(req.Context(), slog.LevelError, "deferred value failed",
+				slog.String("stage", "deferred"),
+				slog.String("url", req.URL.String()),
+				slog.String("id", entry.id),
+				slog.Any("error", err),
+			)
+			content = ""
+		}
+
+		_, err = fmt.Fprintf(wr, `<template id="%s-content">%s</template><script>$torqueResolve("%s")</script>`, entry.id, content, entry.id)
+		if err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// deferredFunc returns the template func rendering the fallback of a Deferred value. Without a
+// queue attached to the request the value is awaited and rendered in place.
+func deferredFunc(req *http.Request) func(value deferredValue, fallback ...any) (template.HTML, error) {
+	return func(value deferredValue, fallback ...any) (template.HTML, error) {
+		if value == nil || reflect.ValueOf(value).IsNil() {
+			return "", nil
+		}
+
+		var q *deferredQueue
+		if req != nil {
+			q, _ = req.Context().Value(deferredContextKey).(*deferredQueue)
+		}
+		if q == nil {
+			return value.render(req)
+		}
+
+		var content template.HTML
+		for _, f := range fallback {
+			if html, ok := f.(template.HTML); ok {
+				content += html
+			} else {
+				content += template.HTML(template.HTMLEscapeString(fmt.Sprint(f)))
+			}
+		}
+
+		return template.HTML(fmt.Sprintf(`<div id="%s" style="display:contents">%s</div>`, q.push(value), content)), nil
+	}
+}
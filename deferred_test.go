@@ -0,0 +1,135 @@
+package torque_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockCommentsTemplateProvider struct {
+	Comments []string `json:"comments"`
+}
+
+func (MockCommentsTemplateProvider) TemplateText() string {
+	return "{{ range .Comments }}<p>{{ . }}</p>{{ end }}"
+}
+
+type MockDeferredTemplateProvider struct {
+	Title    string                                         `json:"title"`
+	Comments *torque.Deferred[MockCommentsTemplateProvider] `json:"comments"`
+}
+
+func (MockDeferredTemplateProvider) TemplateText() string {
+	return `<h1>{{ .Title }}</h1>{{ deferred .Comments "<Loading>" }}`
+}
+
+func TestDeferred_Stream(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		wr      = &flushNotifier{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan struct{})}
+	)
+
+	// the deferred value only resolves once the page has been flushed
+	go func() {
+		<-wr.flushed
+		close(release)
+	}()
+
+	RegisterTestingT(t)
+	h := torque.MustNew[MockDeferredTemplateProvider](&MockLoader[MockDeferredTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockDeferredTemplateProvider, error) {
+			return MockDeferredTemplateProvider{
+				Title: "Post",
+				Comments: torque.Defer(func() (MockCommentsTemplateProvider, error) {
+					<-release
+					return MockCommentsTemplateProvider{Comments: []string{"First!"}}, nil
+				}),
+			}, nil
+		},
+	})
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	body := wr.Body.String()
+	Expect(body).To(HavePrefix(`<h1>Post</h1><div id="torque-deferred-0" style="display:contents">&lt;Loading&gt;</div><script>`))
+	Expect(body).To(HaveSuffix(`<template id="torque-deferred-0-content"><p>First!</p></template><script>$torqueResolve("torque-deferred-0")</script>`))
+}
+
+func TestDeferred_Error(t *testing.T) {
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h := torque.MustNew[MockDeferredTemplateProvider](&MockLoader[MockDeferredTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockDeferredTemplateProvider, error) {
+			return MockDeferredTemplateProvider{
+				Title: "Post",
+				Comments: torque.Defer(func() (MockCommentsTemplateProvider, error) {
+					return MockCommentsTemplateProvider{Comments: []string{"First!"}}, errors.New("failed to load comments")
+				}),
+			}, nil
+		},
+	})
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(HaveSuffix(`<template id="torque-deferred-0-content"></template><script>$torqueResolve("torque-deferred-0")</script>`))
+}
+
+func TestDeferred_Outlet(t *testing.T) {
+	h := torque.MustNew[MockDeferredTemplateProvider](&struct {
+		MockLoader[MockDeferredTemplateProvider]
+		MockLayoutProvider
+	}{
+		MockLoader: MockLoader[MockDeferredTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockDeferredTemplateProvider, error) {
+				return MockDeferredTemplateProvider{
+					Title: "Post",
+					Comments: torque.Defer(func() (MockCommentsTemplateProvider, error) {
+						return MockCommentsTemplateProvider{Comments: []string{"First!"}}, nil
+					}),
+				}, nil
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return createMockMessageLayout("")
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// resolved content is streamed after the layout
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	body := wr.Body.String()
+	Expect(body).To(HavePrefix(`<div><h1>Post</h1><div id="torque-deferred-0" style="display:contents">&lt;Loading&gt;</div></div><script>`))
+	Expect(body).To(HaveSuffix(`<template id="torque-deferred-0-content"><p>First!</p></template><script>$torqueResolve("torque-deferred-0")</script>`))
+}
+
+func TestDeferred_JSON(t *testing.T) {
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	h := torque.MustNew[MockDeferredTemplateProvider](&MockLoader[MockDeferredTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockDeferredTemplateProvider, error) {
+			return MockDeferredTemplateProvider{
+				Title: "Post",
+				Comments: torque.Defer(func() (MockCommentsTemplateProvider, error) {
+					return MockCommentsTemplateProvider{Comments: []string{"First!"}}, nil
+				}),
+			}, nil
+		},
+	})
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(strings.TrimSpace(wr.Body.String())).To(MatchJSON(`{"title":"Post","comments":{"comments":["First!"]}}`))
+}
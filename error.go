@@ -2,7 +2,6 @@ package torque
 
 import (
 	_ "embed"
-	"encoding/json"
 	"errors"
 	"html/template"
 	"net/http"
@@ -39,17 +38,107 @@ func (e errReload) Error() string {
 // the page with the given error attached to the request context.
 //
 // Hint: Get the error with the UseError hook in the Loader and add some error
-// state to the resulting ViewModel.
+// state to the resulting ViewModel. The submitted form is available using the
+// UseFormState hook.
 func ReloadWithError(err error) error {
 	return &errReload{err}
 }
 
+// HTTPError is an error mapped to an HTTP status code. When returned from the Controller
+// API, the response is written with the Status, whether the error is handled by an
+// ErrorBoundary or rendered using the default error template.
+//
+// The Message is shown to the client, so it shouldn't contain any sensitive information.
+// Use Err to wrap the underlying cause of the error.
+type HTTPError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Status)
+	}
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError returns an HTTPError with a custom status code.
+func StatusError(status int, msg string) error {
+	return &HTTPError{Status: status, Message: msg}
+}
+
+// BadRequest returns an HTTPError with the 400 Bad Request status code.
+func BadRequest(msg string) error {
+	return StatusError(http.StatusBadRequest, msg)
+}
+
+// Forbidden returns an HTTPError with the 403 Forbidden status code.
+func Forbidden(msg string) error {
+	return StatusError(http.StatusForbidden, msg)
+}
+
+// NotFound returns an HTTPError with the 404 Not Found status code.
+func NotFound(msg string) error {
+	return StatusError(http.StatusNotFound, msg)
+}
+
+// Conflict returns an HTTPError with the 409 Conflict status code.
+func Conflict(msg string) error {
+	return StatusError(http.StatusConflict, msg)
+}
+
+// StatusCode returns the status code of the first HTTPError in err's chain, or
+// 500 Internal Server Error if there is none. Validation errors returned by the
+// DecodeAndValidate functions have the 400 Bad Request status code.
+func StatusCode(err error) int {
+	status, _ := httpStatus(err)
+	return status
+}
+
+// httpStatus returns the status code of the error and whether it has one.
+func httpStatus(err error) (int, bool) {
+	var (
+		httpErr  *HTTPError
+		validErr *validationError
+	)
+	if errors.As(err, &validErr) {
+		return http.StatusBadRequest, true
+	} else if errors.As(err, &httpErr) {
+		return httpErr.Status, true
+	}
+	return http.StatusInternalServerError, false
+}
+
 var (
 	//go:embed error.tmpl.html
 	errorPageHtml     string
 	errorPageTemplate = template.Must(template.New("error").Parse(errorPageHtml))
+
+	//go:embed status.tmpl.html
+	statusPageHtml     string
+	statusPageTemplate = template.Must(template.New("status").Parse(statusPageHtml))
 )
 
+// writeHTTPError writes an error with a status code to the response using the default
+// error template, or as problem details if the client accepts JSON.
+func writeHTTPError(wr http.ResponseWriter, req *http.Request, err error) error {
+	var problem = NewProblem(req, err)
+	if acceptsProblem(req) {
+		return problem.Write(wr)
+	}
+
+	wr.Header().Set(RequestIDHeader, problem.RequestID)
+	wr.Header().Set("Content-Type", "text/html; charset=utf-8")
+	wr.Header().Set("X-Content-Type-Options", "nosniff")
+	wr.WriteHeader(problem.Status)
+	return statusPageTemplate.Execute(wr, problem)
+}
+
 // errResponse is the data structure used to render an error to the response body.
 type errResponse struct {
 	Error      error
@@ -57,21 +146,40 @@ type errResponse struct {
 }
 
 func writeErrorResponse(wr http.ResponseWriter, req *http.Request, err error, stack []byte) error {
-	var mode = UseMode(req.Context())
+	var (
+		mode    = UseMode(req.Context())
+		problem = NewProblem(req, err)
+	)
+	problem.Status = http.StatusInternalServerError
+	problem.Title = http.StatusText(http.StatusInternalServerError)
+	if mode == ModeDevelopment {
+		problem.Detail = err.Error()
+	} else {
+		problem.Detail = ""
+		problem.InvalidParams = nil
+	}
+
+	// clients accepting JSON receive problem details, which only
+	// include the error and stack trace in development mode
+	if acceptsProblem(req) {
+		if mode == ModeDevelopment {
+			problem.Extensions = map[string]any{"stackTrace": string(stack)}
+		}
+		return problem.Write(wr)
+	}
+
+	wr.Header().Set(RequestIDHeader, problem.RequestID)
 
 	// in development mode, write detailed error reports to the response
 	if mode == ModeDevelopment {
-		defer wr.WriteHeader(http.StatusInternalServerError)
-
 		var res = errResponse{
 			Error:      err,
 			StackTrace: string(stack),
 		}
 
-		switch req.Header.Get("Accept") {
-		case "application/json":
-			return json.NewEncoder(wr).Encode(&res)
-		case "text/html":
+		if req.Header.Get("Accept") == "text/html" {
+			wr.Header().Set("Content-Type", "text/html; charset=utf-8")
+			wr.WriteHeader(http.StatusInternalServerError)
 			return errorPageTemplate.Execute(wr, &res)
 		}
 
@@ -79,6 +187,15 @@ func writeErrorResponse(wr http.ResponseWriter, req *http.Request, err error, st
 		return nil
 	}
 
+	// in production mode, browsers receive the default status page and
+	// other clients a plain text response, without any error details
+	if acceptsHTML(req) {
+		wr.Header().Set("Content-Type", "text/html; charset=utf-8")
+		wr.Header().Set("X-Content-Type-Options", "nosniff")
+		wr.WriteHeader(problem.Status)
+		return statusPageTemplate.Execute(wr, problem)
+	}
+
 	http.Error(wr, "internal server error", http.StatusInternalServerError)
 	return nil
 }
@@ -0,0 +1,99 @@
+package torque_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+func TestHTTPError_DefaultTemplate(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+			return MockTemplateProvider{}, fmt.Errorf("failed to load user: %w", torque.NotFound("user not found"))
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+	Expect(wr.Code).To(Equal(http.StatusNotFound))
+	Expect(wr.Header().Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+	Expect(wr.Body.String()).To(ContainSubstring("<h1>404 Not Found</h1>"))
+	Expect(wr.Body.String()).To(ContainSubstring("<p>user not found</p>"))
+}
+
+func TestHTTPError_ProblemDetails(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+			return MockTemplateProvider{}, torque.StatusError(http.StatusTeapot, "")
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(torque.RequestIDHeader, "abc123")
+	h.ServeHTTP(wr, req)
+	Expect(wr.Code).To(Equal(http.StatusTeapot))
+	Expect(wr.Header().Get("Content-Type")).To(Equal("application/problem+json"))
+	Expect(wr.Header().Get(torque.RequestIDHeader)).To(Equal("abc123"))
+
+	var res map[string]any
+	Expect(json.Unmarshal(wr.Body.Bytes(), &res)).To(Succeed())
+	Expect(res).To(Equal(map[string]any{
+		"type":      "about:blank",
+		"title":     "I'm a teapot",
+		"status":    float64(http.StatusTeapot),
+		"detail":    "I'm a teapot",
+		"instance":  "/",
+		"requestId": "abc123",
+	}))
+}
+
+func TestHTTPError_ErrorBoundary(t *testing.T) {
+	var boundaryErr error
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockErrorBoundary
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{}, torque.Forbidden("members only")
+			},
+		},
+		MockErrorBoundary: MockErrorBoundary{
+			ErrorBoundaryFunc: func(wr http.ResponseWriter, req *http.Request, err error) http.HandlerFunc {
+				boundaryErr = err
+				return func(wr http.ResponseWriter, req *http.Request) {
+					_, _ = wr.Write([]byte(err.Error()))
+				}
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+	Expect(wr.Code).To(Equal(http.StatusForbidden))
+	Expect(wr.Body.String()).To(Equal("members only"))
+	Expect(torque.StatusCode(boundaryErr)).To(Equal(http.StatusForbidden))
+}
+
+func TestHTTPError_StatusCode(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(torque.StatusCode(torque.BadRequest(""))).To(Equal(http.StatusBadRequest))
+	Expect(torque.StatusCode(torque.Conflict("already exists"))).To(Equal(http.StatusConflict))
+	Expect(torque.StatusCode(errors.New("failed"))).To(Equal(http.StatusInternalServerError))
+
+	var err = &torque.HTTPError{Status: http.StatusNotFound, Err: errors.New("sql: no rows")}
+	Expect(err.Error()).To(Equal("Not Found"))
+	Expect(errors.Unwrap(err)).To(MatchError("sql: no rows"))
+}
@@ -0,0 +1,330 @@
+package torque
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// subscriber is a single subscription to an EventSource. It is passed to Subscribe as its
+// http.ResponseWriter, buffering each event until it is flushed, so events sent by
+// Broadcast are never interleaved with the events written by the EventSource.
+type subscriber struct {
+	mu          sync.Mutex
+	wr          http.ResponseWriter
+	buf         bytes.Buffer
+	wroteHeader bool
+
+	cancel context.CancelFunc
+	closed bool
+
+	// sentShutdown reports whether the final ShutdownEvent was written, either by
+	// torque or by the EventSource itself, i.e. an htmx.SSE stream
+	sentShutdown bool
+}
+
+// shutdownEvent is the final event sent to subscribers once the Server shuts down.
+var shutdownEvent = formatEvent(ShutdownEvent, "")
+
+func (s *subscriber) Header() http.Header {
+	return s.wr.Header()
+}
+
+func (s *subscriber) WriteHeader(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.wr.WriteHeader(code)
+	}
+}
+
+func (s *subscriber) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// events written after the subscription was closed, i.e. the final event of an
+	// htmx.SSE stream during shutdown, have already been sent by torque
+	if s.closed {
+		return len(b), nil
+	}
+	if bytes.Equal(b, shutdownEvent) {
+		s.sentShutdown = true
+	}
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.wr.WriteHeader(http.StatusOK)
+	}
+	return s.buf.Write(b)
+}
+
+func (s *subscriber) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = s.flush()
+}
+
+func (s *subscriber) Unwrap() http.ResponseWriter {
+	return s.wr
+}
+
+// flush writes the buffered events to the client. s.mu must be held.
+func (s *subscriber) flush() error {
+	if s.buf.Len() != 0 {
+		_, err := s.wr.Write(s.buf.Bytes())
+		s.buf.Reset()
+		if err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(s.wr).Flush()
+}
+
+// send writes a single event to the client, unless the EventSource hasn't started
+// streaming yet.
+func (s *subscriber) send(event []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.wroteHeader || s.closed {
+		return
+	}
+	if _, err := s.buf.Write(event); err != nil || s.flush() != nil {
+		// the client is gone, stop the subscription
+		s.close()
+	}
+}
+
+// close cancels the context of the subscription. s.mu must be held.
+func (s *subscriber) close() {
+	s.closed = true
+	s.cancel()
+}
+
+// shutdown sends the final ShutdownEvent and closes the subscription, unless it
+// is already closed.
+func (s *subscriber) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	if s.wroteHeader && !s.sentShutdown {
+		s.sentShutdown = true
+		if _, err := s.buf.Write(shutdownEvent); err == nil {
+			_ = s.flush()
+		}
+	}
+	s.close()
+}
+
+// subscriptions are the live subscribers of a Handler's EventSource, and of the
+// EventSources of every Handler mounted to it.
+type subscriptions struct {
+	mu  sync.Mutex
+	own int
+	set map[*subscriber]struct{}
+}
+
+// add registers the subscriber, unless the Handler already has max subscribers of its own.
+func (s *subscriptions) add(sub *subscriber, own bool, max int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if own {
+		if max > 0 && s.own >= max {
+			return false
+		}
+		s.own++
+	}
+	if s.set == nil {
+		s.set = make(map[*subscriber]struct{})
+	}
+	s.set[sub] = struct{}{}
+	return true
+}
+
+func (s *subscriptions) remove(sub *subscriber, own bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if own {
+		s.own--
+	}
+	delete(s.set, sub)
+}
+
+func (s *subscriptions) list() []*subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res = make([]*subscriber, 0, len(s.set))
+	for sub := range s.set {
+		res = append(res, sub)
+	}
+	return res
+}
+
+func (h *handlerImpl[T]) getSubscriptions() *subscriptions {
+	return &h.subscribers
+}
+
+func (h *handlerImpl[T]) getMount() Handler {
+	return h.mount
+}
+
+// Subscribers returns the number of live subscribers of the Handler's EventSource and
+// the EventSources of every Handler in its route tree.
+func (h *handlerImpl[T]) Subscribers() int {
+	return len(h.subscribers.list())
+}
+
+// Broadcast sends an event to every live subscriber of the Handler's EventSource and the
+// EventSources of every Handler in its route tree. Subscribers that haven't started
+// streaming yet, i.e. haven't written their headers, don't receive the event.
+//
+// The event is written in the text/event-stream format. An empty event name sends an
+// unnamed message event.
+func (h *handlerImpl[T]) Broadcast(event, data string) {
+	var byt = formatEvent(event, data)
+	for _, sub := range h.subscribers.list() {
+		sub.send(byt)
+	}
+}
+
+// formatEvent formats an event in the text/event-stream format.
+func formatEvent(event, data string) []byte {
+	var buf bytes.Buffer
+	if event != "" {
+		buf.WriteString("event: " + event + "\n")
+	}
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// CloseSubscribers cancels the request context of every live subscriber of the Handler's
+// EventSource and the EventSources of every Handler in its route tree. EventSources are
+// expected to return from Subscribe once their request context is done.
+func (h *handlerImpl[T]) CloseSubscribers() {
+	for _, sub := range h.subscribers.list() {
+		sub.mu.Lock()
+		sub.close()
+		sub.mu.Unlock()
+	}
+}
+
+// subscribe registers the subscriber with the Handler and every Handler it is mounted to.
+// It returns false if the Handler has reached its SubscriberLimit.
+func (h *handlerImpl[T]) subscribe(sub *subscriber) bool {
+	var max = 0
+	if h.subscriberLimit != nil {
+		max = h.subscriberLimit.MaxSubscribers()
+	}
+	if ok := h.subscribers.add(sub, true, max); !ok {
+		return false
+	}
+	for mount := h.mount; mount != nil; mount = mount.getMount() {
+		mount.getSubscriptions().add(sub, false, 0)
+	}
+	return true
+}
+
+func (h *handlerImpl[T]) unsubscribe(sub *subscriber) {
+	h.subscribers.remove(sub, true)
+	for mount := h.mount; mount != nil; mount = mount.getMount() {
+		mount.getSubscriptions().remove(sub, false)
+	}
+}
+
+func (h *handlerImpl[T]) handleEventSource(wr http.ResponseWriter, req *http.Request) error {
+	if h.eventSource == nil {
+		return fmt.Errorf("failed to handle event source: %w", errNotImplemented)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	var sub = &subscriber{wr: wr, cancel: cancel}
+	if ok := h.subscribe(sub); !ok {
+		h.logStage(req, slog.LevelWarn, "event_source", "too many subscribers", slog.Int("status", http.StatusServiceUnavailable))
+		http.Error(wr, "too many subscribers", http.StatusServiceUnavailable)
+		return nil
+	}
+	defer h.unsubscribe(sub)
+
+	var pattern = routePattern(req, h.path)
+	h.requestOptions(req).metrics.addSubscribers(pattern, 1)
+	defer h.requestOptions(req).metrics.addSubscribers(pattern, -1)
+
+	// subscriptions are closed once the Server shuts down
+	var (
+		shutdown = UseShutdown(req)
+		done     = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		select {
+		case <-shutdown:
+			sub.shutdown()
+		case <-ctx.Done():
+		}
+	}()
+
+	req = req.WithContext(ctx)
+	if h.subscribeHook != nil {
+		if err := h.subscribeHook.OnSubscribe(req); err != nil {
+			h.logStage(req, slog.LevelInfo, "event_source", "subscription rejected", slog.Any("error", err))
+			return err
+		}
+	}
+
+	h.logStage(req, slog.LevelDebug, "event_source", "subscribed", slog.Int("subscribers", h.Subscribers()))
+	err := h.eventSource.Subscribe(sub, req)
+
+	// EventSources returning once the Server shuts down are sent the final
+	// event here, so it isn't written after the handler returned
+	select {
+	case <-shutdown:
+		sub.shutdown()
+	default:
+	}
+
+	// nothing is written to the subscription once Subscribe returned
+	sub.mu.Lock()
+	closed := sub.closed
+	sub.closed = true
+	if sub.wroteHeader {
+		_ = sub.flush()
+	}
+	sub.mu.Unlock()
+
+	cancel()
+	<-done
+
+	if h.unsubscribeHook != nil {
+		h.unsubscribeHook.OnUnsubscribe(req)
+	}
+
+	// subscriptions closed by CloseSubscribers or a shutdown are not an error
+	if closed && errors.Is(err, context.Canceled) {
+		err = nil
+	}
+
+	if err != nil {
+		h.logStage(req, slog.LevelError, "event_source", "subscription failed", slog.Any("error", err))
+	} else {
+		h.logStage(req, slog.LevelDebug, "event_source", "unsubscribed", slog.Bool("closed", closed))
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package torque_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockSubscribeHook struct {
+	OnSubscribeFunc func(req *http.Request) error
+}
+
+func (m MockSubscribeHook) OnSubscribe(req *http.Request) error {
+	return m.OnSubscribeFunc(req)
+}
+
+type MockUnsubscribeHook struct {
+	OnUnsubscribeFunc func(req *http.Request)
+}
+
+func (m MockUnsubscribeHook) OnUnsubscribe(req *http.Request) {
+	m.OnUnsubscribeFunc(req)
+}
+
+type MockSubscriberLimit struct {
+	Max int
+}
+
+func (m MockSubscriberLimit) MaxSubscribers() int {
+	return m.Max
+}
+
+// mockStreamingEventSource starts streaming and blocks until the subscription is closed
+func mockStreamingEventSource(subscribed chan struct{}) MockEventSource {
+	return MockEventSource{
+		SubscribeFunc: func(wr http.ResponseWriter, req *http.Request) error {
+			wr.Header().Set("Content-Type", "text/event-stream")
+			wr.WriteHeader(http.StatusOK)
+			wr.(http.Flusher).Flush()
+
+			subscribed <- struct{}{}
+			<-req.Context().Done()
+			return req.Context().Err()
+		},
+	}
+}
+
+// subscribe serves an event source request in the background
+func subscribe(h http.Handler, path string) (*httptest.ResponseRecorder, chan struct{}) {
+	var (
+		wr   = httptest.NewRecorder()
+		done = make(chan struct{})
+		req  = httptest.NewRequest(http.MethodGet, path, nil)
+	)
+	req.Header.Set("Accept", "text/event-stream")
+	go func() {
+		defer close(done)
+		h.ServeHTTP(wr, req)
+	}()
+	return wr, done
+}
+
+func TestEventSource_BroadcastAndClose(t *testing.T) {
+	var subscribed = make(chan struct{})
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/events", torque.MustNew[any](&struct{ MockEventSource }{mockStreamingEventSource(subscribed)}))
+		},
+	})
+
+	RegisterTestingT(t)
+	wr, done := subscribe(h, "/events")
+	<-subscribed
+	Expect(h.Subscribers()).To(Equal(1))
+
+	h.Broadcast("shutdown", "goodbye\nworld")
+	h.CloseSubscribers()
+	<-done
+
+	Expect(h.Subscribers()).To(Equal(0))
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(Equal("event: shutdown\ndata: goodbye\ndata: world\n\n"))
+}
+
+func TestEventSource_BroadcastHost(t *testing.T) {
+	var subscribed = make(chan struct{})
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/", torque.MustNew[any](&MockRouterProvider{
+				RouterFunc: func(r torque.Router) {
+					r.Host("{tenant}.example.com", torque.MustNew[any](&MockRouterProvider{
+						RouterFunc: func(r torque.Router) {
+							r.Handle("/events", torque.MustNew[any](&struct{ MockEventSource }{mockStreamingEventSource(subscribed)}))
+						},
+					}))
+				},
+			}))
+		},
+	})
+
+	RegisterTestingT(t)
+	var (
+		wr   = httptest.NewRecorder()
+		done = make(chan struct{})
+		req  = httptest.NewRequest(http.MethodGet, "/events", nil)
+	)
+	req.Host = "acme.example.com"
+	req.Header.Set("Accept", "text/event-stream")
+	go func() {
+		defer close(done)
+		h.ServeHTTP(wr, req)
+	}()
+	<-subscribed
+	Expect(h.Subscribers()).To(Equal(1))
+
+	h.Broadcast("message", "hello")
+	h.CloseSubscribers()
+	<-done
+
+	Expect(h.Subscribers()).To(Equal(0))
+	Expect(wr.Body.String()).To(Equal("event: message\ndata: hello\n\n"))
+}
+
+func TestEventSource_SubscriberLimit(t *testing.T) {
+	var subscribed = make(chan struct{})
+	h := torque.MustNew[any](&struct {
+		MockEventSource
+		MockSubscriberLimit
+	}{
+		MockEventSource:     mockStreamingEventSource(subscribed),
+		MockSubscriberLimit: MockSubscriberLimit{Max: 1},
+	})
+
+	RegisterTestingT(t)
+	_, done := subscribe(h, "/")
+	<-subscribed
+
+	wr, overflow := subscribe(h, "/")
+	<-overflow
+	Expect(wr.Code).To(Equal(http.StatusServiceUnavailable))
+
+	h.CloseSubscribers()
+	<-done
+	Expect(h.Subscribers()).To(Equal(0))
+}
+
+func TestEventSource_Hooks(t *testing.T) {
+	var (
+		subscribed   = make(chan struct{})
+		unsubscribed atomic.Bool
+	)
+	h := torque.MustNew[any](&struct {
+		MockEventSource
+		MockSubscribeHook
+		MockUnsubscribeHook
+		MockErrorBoundary
+	}{
+		MockEventSource: mockStreamingEventSource(subscribed),
+		MockSubscribeHook: MockSubscribeHook{
+			OnSubscribeFunc: func(req *http.Request) error {
+				if req.URL.Query().Get("token") == "" {
+					return errors.New("unauthorized")
+				}
+				return nil
+			},
+		},
+		MockUnsubscribeHook: MockUnsubscribeHook{
+			OnUnsubscribeFunc: func(req *http.Request) {
+				unsubscribed.Store(true)
+			},
+		},
+		MockErrorBoundary: mockFailingErrorBoundary,
+	})
+
+	RegisterTestingT(t)
+	wr, rejected := subscribe(h, "/")
+	<-rejected
+	Expect(wr.Code).To(Equal(http.StatusInternalServerError))
+	Expect(wr.Body.String()).To(ContainSubstring("unauthorized"))
+	Expect(unsubscribed.Load()).To(BeFalse())
+
+	_, done := subscribe(h, "/?token=abc")
+	<-subscribed
+	h.CloseSubscribers()
+	<-done
+	Expect(unsubscribed.Load()).To(BeTrue())
+}
+
+func TestEventSource_ConcurrentSubscribers(t *testing.T) {
+	var (
+		count = 50
+		wg    sync.WaitGroup
+	)
+	h := torque.MustNew[any](&MockEventSource{
+		SubscribeFunc: func(wr http.ResponseWriter, req *http.Request) error {
+			return nil
+		},
+	})
+
+	RegisterTestingT(t)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, done := subscribe(h, "/")
+			<-done
+		}()
+	}
+	wg.Wait()
+	Expect(h.Subscribers()).To(Equal(0))
+}
@@ -3,6 +3,7 @@ package torque
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/gorilla/schema"
@@ -21,8 +22,25 @@ var (
 	ErrFormValidationFailure      = errors.New("failed to validate form data")
 	ErrQueryValidationFailure     = errors.New("failed to validate query data")
 	ErrPathParamValidationFailure = errors.New("failed to validate path parameters")
+	ErrPathParamDecodeFailure     = errors.New("failed to decode path parameters")
 )
 
+// validationError is returned by the Decode functions when the decoded value is
+// invalid. It wraps both the sentinel error of the function and the error returned
+// by Validate, or the ValidationErrors of the decoder and the ValidateTag rules.
+type validationError struct {
+	sentinel error
+	err      error
+}
+
+func (e *validationError) Error() string {
+	return e.sentinel.Error() + ": " + e.err.Error()
+}
+
+func (e *validationError) Unwrap() []error {
+	return []error{e.sentinel, e.err}
+}
+
 // IsMultipartForm checks the Content-Type header to see if the request is a
 // multipart form submission.
 func IsMultipartForm(req *http.Request) bool {
@@ -49,29 +67,37 @@ func DecodeFormAction(req *http.Request) string {
 	return req.Form.Get("action")
 }
 
+// DecodeForm decodes the form data of the request into a new T, applying the
+// ValidateTag rules of its fields.
 func DecodeForm[T any](req *http.Request) (*T, error) {
-	if req.Form == nil {
-		err := req.ParseForm()
-		if err != nil {
-			return nil, errors.Wrap(err, ErrFormParseFailure.Error())
-		}
+	res, err := decodeForm[T](req)
+	if err != nil {
+		return nil, err
 	}
 
-	d, ok := UseDecoder(req)
-	if !ok {
-		return nil, ErrDecoderUndefined
+	if err := validateDecoded(req.Context(), res, nil, ErrFormValidationFailure); err != nil {
+		return nil, err
 	}
 
-	var res T
-	err := d.Decode(&res, req.PostForm)
+	return res, nil
+}
+
+// DecodeAndValidateForm decodes the form data of the request like DecodeForm and
+// validates it using its Validate method.
+func DecodeAndValidateForm[T SelfValidator](req *http.Request) (*T, error) {
+	res, err := decodeForm[T](req)
 	if err != nil {
-		return nil, errors.Wrap(err, ErrFormDecodeFailure.Error())
+		return nil, err
 	}
 
-	return &res, nil
+	if err := validateDecoded(req.Context(), res, *res, ErrFormValidationFailure); err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
-func DecodeAndValidateForm[T SelfValidator](req *http.Request) (*T, error) {
+func decodeForm[T any](req *http.Request) (*T, error) {
 	if req.Form == nil {
 		err := req.ParseForm()
 		if err != nil {
@@ -86,15 +112,66 @@ func DecodeAndValidateForm[T SelfValidator](req *http.Request) (*T, error) {
 
 	var res T
 	err := d.Decode(&res, req.PostForm)
-	if err != nil {
+	recordFormState(req, &res, req.PostForm)
+	if errs, ok := fromSchemaError(err); ok {
+		return nil, &validationError{ErrFormDecodeFailure, errs}
+	} else if err != nil {
 		return nil, errors.Wrap(err, ErrFormDecodeFailure.Error())
 	}
 
-	if err := res.Validate(req.Context()); err != nil {
-		return nil, errors.Wrap(err, ErrFormValidationFailure.Error())
+	return &res, nil
+}
+
+// formState is the form submitted to an Action, kept in the request context so it
+// can be re-rendered after a ReloadWithError.
+type formState struct {
+	value  any
+	values url.Values
+}
+
+func withFormState(req *http.Request) *http.Request {
+	return With(req, formStateContextKey, &formState{})
+}
+
+// recordFormState records the form values decoded by the decode helpers, along
+// with the decoded value.
+func recordFormState(req *http.Request, value any, values url.Values) {
+	if state, ok := Use[*formState](req, formStateContextKey); ok {
+		state.value, state.values = value, values
 	}
+}
 
-	return &res, nil
+// recordFormValues records the form values parsed by an Action, unless they were
+// already recorded by the decode helpers.
+func recordFormValues(req *http.Request, values url.Values) {
+	if state, ok := Use[*formState](req, formStateContextKey); ok && state.values == nil {
+		state.values = values
+	}
+}
+
+// UseFormState returns the form submitted to the Action and its ValidationErrors after
+// the Action returned ReloadWithError, so the Loader can re-populate the inputs of the
+// form. The form is decoded into T, even if some of its values are invalid.
+//
+// A zero T and empty ValidationErrors are returned if no form was submitted.
+func UseFormState[T any](req *http.Request) (T, ValidationErrors) {
+	var (
+		res  T
+		errs = UseValidationErrors(req)
+	)
+
+	state, ok := Use[*formState](req, formStateContextKey)
+	if !ok {
+		return res, errs
+	}
+	if value, ok := state.value.(*T); ok {
+		return *value, errs
+	}
+	if d, ok := UseDecoder(req); ok && state.values != nil {
+		// values that can't be decoded into T are left empty
+		_ = d.Decode(&res, state.values)
+	}
+	return res, errs
 }
 
 func EncodeForm[T any](req *http.Request, formData *T) error {
@@ -5,10 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/http/httptest"
 	"runtime/debug"
 	"time"
 
@@ -25,11 +23,18 @@ type handlerImpl[T ViewModel] struct {
 	router   *router
 	path     string
 	parent   Handler
+	mount    Handler
 	children []Handler
 	override http.Handler
 
-	subscribers int
-	eventSource EventSource
+	notFound         Handler
+	methodNotAllowed Handler
+
+	subscribers     subscriptions
+	eventSource     EventSource
+	subscribeHook   SubscribeHook
+	unsubscribeHook UnsubscribeHook
+	subscriberLimit SubscriberLimit
 
 	handler       http.Handler
 	action        Action
@@ -39,9 +44,17 @@ type handlerImpl[T ViewModel] struct {
 	rendererVM    DynamicRenderer
 	guards        []Guard
 	plugins       []Plugin
+	middlewares   []Middleware
 	errorBoundary ErrorBoundary
 	panicBoundary PanicBoundary
 	hookProvider  HookProvider
+	opts          options
+
+	deadlineProvider DeadlineProvider
+	deadlineBoundary DeadlineBoundary
+	cancelBoundary   CancelBoundary
+
+	outletStreamer OutletStreamer
 }
 
 func createHandlerImpl[T ViewModel]() *handlerImpl[T] {
@@ -67,6 +80,7 @@ func createHandlerImpl[T ViewModel]() *handlerImpl[T] {
 		panicBoundary: nil,
 		guards:        []Guard{},
 		plugins:       []Plugin{},
+		middlewares:   []Middleware{},
 	}
 
 	h.encoder.SetAliasTag("json")
@@ -77,65 +91,64 @@ func createHandlerImpl[T ViewModel]() *handlerImpl[T] {
 
 // ServeHTTP implements the http.Handler interface
 func (h *handlerImpl[T]) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
+	// requests are assigned an ID once, which is logged with every stage
+	// and written with errors so they can be correlated
+	req = withRequestID(wr, req)
+
 	didRouteMatch, ok := req.Context().Value(routerMatchContextKey).(bool)
 	didRouteMatch = didRouteMatch && ok
 
-	if h.router != nil && !didRouteMatch {
-		log.Printf("[Router] (%s) %s -> %T\n", req.Method, req.URL, h.ctl)
+	if h.router == nil && !didRouteMatch && len(h.middlewares) != 0 {
+		// Handlers matched by a router have their middleware applied by the
+		// router. Standalone handlers have to apply it themselves.
+		ctx := context.WithValue(req.Context(), routerMatchContextKey, true)
+		applyMiddleware(h, []*middlewareStack{{middlewares: h.middlewares}}).ServeHTTP(wr, req.WithContext(ctx))
+	} else if h.router != nil && !didRouteMatch {
+		h.logStage(req, slog.LevelDebug, "router", "routing request")
 		// Indicate to any handlers they should not attempt to handle the request using
 		// their internal router because the request will have already been matched
 		ctx := context.WithValue(req.Context(), routerMatchContextKey, true)
 		// Match the request with the router
 		h.router.ServeHTTP(wr, req.WithContext(ctx))
-	} else if req.Method == http.MethodGet && h.GetParent() != nil && h.GetParent().HasOutlet() {
-		h.serveOutlet(wr, req)
-	} else {
-		_ = h.serveRequest(wr, req)
-	}
-}
+	} else if isGetOrHead(req) && h.GetParent() != nil && h.GetParent().HasOutlet() {
+		req, span := h.startRequestSpan(req)
+		defer span.End()
 
-func (h *handlerImpl[T]) serveOutlet(wr http.ResponseWriter, req *http.Request) {
-	var (
-		childReq   = req
-		childResp  = httptest.NewRecorder()
-		parentReq  = req.Clone(req.Context())
-		parentResp = httptest.NewRecorder()
-	)
+		wr, req, observe := h.withRequestMetrics(wr, req)
+		defer observe()
 
-	// child before parent, because it can set additional context
-	// while handling the request
-	childReq = h.serveRequest(childResp, childReq)
-	if childResp.Code != http.StatusOK {
-		// child route is indicating a non-200 error code, do not
-		// render as outlet, maybe it's a redirect
-		for key := range childResp.Header() {
-			wr.Header().Set(key, childResp.Header().Get(key))
-		}
-		wr.WriteHeader(childResp.Code)
-		_, err := wr.Write(childResp.Body.Bytes())
-		if err != nil {
-			panic(err)
+		req, cancel := h.withDeadline(req)
+		defer cancel()
+
+		// Deferred values are streamed once the entire page has been written
+		req, streamDeferred := withDeferredQueue(req, h.requestOptions(req).getLogger())
+		if ok := h.serveOutlet(wr, req); ok {
+			streamDeferred(wr, req)
 		}
-		return
-	}
+	} else {
+		// anything rendered by serveRequest has been written to wr, including
+		// pages rendered after an Action returned ReloadWithError
+		req, span := h.startRequestSpan(req)
+		defer span.End()
 
-	// pass the childReq context here, because it might have been modified by hooks
-	h.GetParent().ServeHTTP(parentResp, parentReq.WithContext(childReq.Context()))
-	t := template.Must(template.New("outlet").Parse(parentResp.Body.String()))
+		wr, req, observe := h.withRequestMetrics(wr, req)
+		defer observe()
 
-	for key := range childResp.Header() {
-		wr.Header().Set(key, childResp.Header().Get(key))
-	}
-	for key := range parentResp.Header() {
-		wr.Header().Set(key, parentResp.Header().Get(key))
-	}
+		req, cancel := h.withDeadline(req)
+		defer cancel()
 
-	err := t.Execute(wr, template.HTML(childResp.Body.String()))
-	if err != nil {
-		panic(err)
+		req, streamDeferred := withDeferredQueue(req, h.requestOptions(req).getLogger())
+		_ = h.serveRequest(wr, req)
+		streamDeferred(wr, req)
 	}
 }
 
+// isGetOrHead reports whether the request is served by the Loader and Renderer.
+// HEAD requests are handled like GET requests, net/http discards their body.
+func isGetOrHead(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
 // serveRequest is the core handler logic for torque. It is responsible for handling incoming
 // HTTP requests and applying the appropriate API methods from the Controller API.
 //
@@ -143,6 +156,22 @@ func (h *handlerImpl[T]) serveOutlet(wr http.ResponseWriter, req *http.Request)
 // and plugins. This is only needed during serveOutlet, where the request context is then passed
 // along to the parent handler.
 func (h *handlerImpl[T]) serveRequest(wr http.ResponseWriter, req *http.Request) *http.Request {
+	req = h.prepareRequest(wr, req)
+	if req == nil {
+		return nil
+	}
+
+	if ok := h.handleRequest(wr, req); !ok {
+		return nil
+	}
+
+	// return request, in case it was modified by any hooks or plugins
+	return req
+}
+
+// prepareRequest sets up the request context before it is handled by the Controller. It
+// returns nil if the request has already been handled, i.e. by a guard or an ErrorBoundary.
+func (h *handlerImpl[T]) prepareRequest(wr http.ResponseWriter, req *http.Request) (res *http.Request) {
 	var err error
 	// attach the decoder to the request context so it can be used
 	// by handlers in the request stack
@@ -152,11 +181,11 @@ func (h *handlerImpl[T]) serveRequest(wr http.ResponseWriter, req *http.Request)
 	defer func() {
 		if err, ok := recover().(error); ok && err != nil {
 			h.handlePanic(wr, req, err)
-			return
+			res = nil
 		}
 	}()
 
-	log.Printf("[Request] (%s) %s -> %T\n", req.Method, req.URL, h.ctl)
+	h.logStage(req, slog.LevelDebug, "request", "handling request")
 
 	// some request headers set by the client can be used to
 	// affect the request context
@@ -184,74 +213,115 @@ func (h *handlerImpl[T]) serveRequest(wr http.ResponseWriter, req *http.Request)
 
 	// guards can prevent a request from going through by
 	// returning an alternate http.HandlerFunc
+	if ok := h.handleGuards(wr, req); !ok {
+		return nil
+	}
+
+	return req
+}
+
+// handleGuards runs the guards of the Handler in order. It returns false if
+// the request was handled by one of them.
+func (h *handlerImpl[T]) handleGuards(wr http.ResponseWriter, req *http.Request) bool {
+	if len(h.guards) == 0 {
+		return true
+	}
+
+	_, span := h.startSpan(req, "torque.guards")
 	for _, guard := range h.guards {
-		if h := guard(req); h != nil {
-			log.Printf("[Guard] %s -> handled by %T\n", req.URL, guard)
-			h(wr, req)
-			return nil
+		if fn := guard(req); fn != nil {
+			span.SetAttributes(slog.String("guard", fmt.Sprintf("%T", guard)))
+			span.End()
+
+			h.logStage(req, slog.LevelInfo, "guard", "request handled by guard", slog.String("guard", fmt.Sprintf("%T", guard)))
+			fn(wr, req)
+			return false
 		}
 	}
+	span.End()
+
+	return true
+}
+
+// handleRequest handles a request prepared by prepareRequest using the Controller API. It
+// returns false if the request resulted in an error.
+func (h *handlerImpl[T]) handleRequest(wr http.ResponseWriter, req *http.Request) (ok bool) {
+	var err error
+
+	// defer a panic recoverer and pass panics to the PanicBoundary
+	defer func() {
+		if err, isErr := recover().(error); isErr && err != nil {
+			h.handlePanic(wr, req, err)
+			ok = false
+		}
+	}()
 
 	// If this is a wrapped vanilla http.Handler passed from a call to torque.MustNewV,
 	// it short-circuits a majority of the controller flow. Just serve the request.
 	if h.handler != nil {
 		h.handler.ServeHTTP(wr, req)
-		return nil
+		return false
 	}
 
 	switch req.Method {
-	case http.MethodGet:
+	case http.MethodGet, http.MethodHead:
 		if req.Header.Get("Accept") == "text/event-stream" {
 			err = h.handleEventSource(wr, req)
 			if err != nil {
 				h.handleError(wr, req, err)
 			}
-			return nil
+			return false
 		}
 
 		vm, err := h.handleLoader(wr, req)
 		if err != nil && !errors.Is(err, errNotImplemented) {
 			h.handleError(wr, req, err)
-			return nil
+			return false
 		}
 
 		err = h.handleRenderHeaders(wr, req, vm)
 		if err != nil {
 			h.handleError(wr, req, err)
-			return nil
+			return false
 		}
 
 		err = h.handleRender(wr, req, vm)
 		if err != nil {
 			h.handleError(wr, req, err)
-			return nil
+			return false
 		}
 
 	case http.MethodPut, http.MethodPost, http.MethodPatch, http.MethodDelete:
+		// the submitted form is kept for the Loader in case of a ReloadWithError
+		req = withFormState(req)
 		err = h.handleAction(wr, req)
 		if err != nil {
 			h.handleError(wr, req, err)
-			return nil
+			return false
 		}
 
 	default:
 		http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
-		return nil
+		return false
 	}
 
-	// return request, in case it was modified by any hooks or plugins
-	return req
+	return true
 }
 
 func (h *handlerImpl[T]) handleAction(wr http.ResponseWriter, req *http.Request) error {
 	var start = time.Now()
 	if h.action != nil {
-		err := h.action.Action(wr, req)
+		spanReq, span := h.startSpan(req, "torque.action")
+		err := h.action.Action(wr, spanReq)
+		endSpan(span, err)
+		recordFormValues(req, spanReq.PostForm)
 		if err != nil {
-			log.Printf("[Action] %s -> error: %s\n", req.URL, err.Error())
+			if !isCanceled(req, err) {
+				h.logStage(req, slog.LevelError, "action", "action failed", slog.Any("error", err), slog.Duration("duration", time.Since(start)))
+			}
 			return err
 		} else {
-			log.Printf("[Action] %s -> success (%dms)\n", req.URL, time.Since(start).Milliseconds())
+			h.logStage(req, slog.LevelDebug, "action", "action succeeded", slog.Duration("duration", time.Since(start)))
 			return nil
 		}
 	} else {
@@ -260,20 +330,34 @@ func (h *handlerImpl[T]) handleAction(wr http.ResponseWriter, req *http.Request)
 }
 
 func (h *handlerImpl[T]) handleError(wr http.ResponseWriter, req *http.Request, err error) {
-	if ok := h.handleReloadError(wr, req, err); ok {
+	// ErrorBoundaries handling an HTTPError or validation error respond
+	// with its status code, unless they write a status code of their own
+	var (
+		status, hasStatus = httpStatus(err)
+		statusWr          = wr
+	)
+	if hasStatus {
+		statusWr = &statusResponseWriter{ResponseWriter: wr, status: status}
+	}
+
+	if ok := h.handleCanceledError(wr, req, err); ok {
+		return
+	} else if ok := h.handleReloadError(wr, req, err); ok {
 		return
 	} else if ok = h.handleRedirectError(wr, req, err); ok {
 		return
 	} else if ok := h.handleInternalError(wr, req, err); ok {
-		log.Printf("[Error] %s", err.Error())
+		return
+	} else if ok := h.handleDeadlineError(wr, req, err); ok {
 		return
 	} else if h.errorBoundary != nil {
 		// Calls to ErrorBoundary can return an http.HandlerFunc
 		// that can be used to cleanly handle the error. Or not
-		h := h.errorBoundary.ErrorBoundary(wr, req, err)
-		if h != nil {
-			log.Printf("[ErrorBoundary] %s -> handled\n", req.URL)
-			h(wr, req)
+		fn := h.errorBoundary.ErrorBoundary(wr, req, err)
+		if fn != nil {
+			h.logStage(req, slog.LevelInfo, "error_boundary", "error handled", slog.Any("error", err))
+			h.observeBoundary(req, "error_boundary")
+			fn(statusWr, req)
 			return
 		}
 	} else if h.parent != nil {
@@ -286,10 +370,11 @@ func (h *handlerImpl[T]) handleError(wr http.ResponseWriter, req *http.Request,
 			}
 
 			if errorBoundary := parent.GetErrorBoundary(); errorBoundary != nil {
-				h := errorBoundary.ErrorBoundary(wr, req, err)
-				if h != nil {
-					log.Printf("[ErrorBoundary] %s -> handled by %T\n", req.URL, parent)
-					h(wr, req)
+				fn := errorBoundary.ErrorBoundary(wr, req, err)
+				if fn != nil {
+					h.logStage(req, slog.LevelInfo, "error_boundary", "error handled by parent", slog.Any("error", err), slog.String("boundary", fmt.Sprintf("%T", parent.getController())))
+					h.observeBoundary(req, "error_boundary")
+					fn(statusWr, req)
 					return
 				}
 			}
@@ -298,31 +383,92 @@ func (h *handlerImpl[T]) handleError(wr http.ResponseWriter, req *http.Request,
 		}
 	}
 
+	// HTTPErrors and validation errors not handled by an
+	// ErrorBoundary are rendered using the default error template
+	if hasStatus {
+		h.logStage(req, slog.LevelInfo, "error", "http error", slog.Any("error", err), slog.Int("status", status))
+		if err := writeHTTPError(wr, req, err); err != nil {
+			h.logStage(req, slog.LevelError, "error", "failed to write error response", slog.Any("error", err))
+		}
+		return
+	}
+
 	// No ErrorBoundary was able to catch the error
 	// So your error goes to the PanicBoundary.
-	log.Printf("[ErrorBoundary] %s -> uncaught error\n", req.URL)
+	h.logStage(req, slog.LevelWarn, "error_boundary", "uncaught error", slog.Any("error", err))
 	panic(err)
 }
 
-func (h *handlerImpl[T]) handleEventSource(wr http.ResponseWriter, req *http.Request) error {
-	if h.eventSource != nil {
-		h.subscribers++
-		log.Printf("[EventSource] %s -> new subscriber (%d total)\n", req.URL, h.subscribers)
-		err := h.eventSource.Subscribe(wr, req)
-		h.subscribers--
-		if err != nil {
-			log.Printf("[EventSource] %s -> closed error: %s\n", req.URL, err.Error())
-		} else {
-			log.Printf("[EventSource] %s -> closed ok (%d total)\n", req.URL, h.subscribers)
+// withDeadline applies the deadline of the DeadlineProvider to the request context.
+// The returned func must be called once the request has been handled.
+func (h *handlerImpl[T]) withDeadline(req *http.Request) (*http.Request, context.CancelFunc) {
+	if h.deadlineProvider == nil {
+		return req, func() {}
+	}
+
+	deadline, ok := h.deadlineProvider.Deadline(req)
+	if !ok {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithDeadline(req.Context(), deadline)
+	return req.WithContext(ctx), cancel
+}
+
+func (h *handlerImpl[T]) handleDeadlineError(wr http.ResponseWriter, req *http.Request, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Walk up the tree of handlers to find the nearest DeadlineBoundary. If
+	// none handles the error, it is passed on to the ErrorBoundary.
+	for handler := Handler(h); handler != nil; handler = handler.GetParent() {
+		if deadlineBoundary := handler.getDeadlineBoundary(); deadlineBoundary != nil {
+			fn := deadlineBoundary.DeadlineBoundary(wr, req)
+			if fn != nil {
+				h.logStage(req, slog.LevelInfo, "deadline_boundary", "deadline exceeded", slog.Any("error", err), slog.String("boundary", fmt.Sprintf("%T", handler.getController())))
+				h.observeBoundary(req, "deadline_boundary")
+				fn(wr, req)
+				return true
+			}
 		}
-		return err
-	} else {
-		return fmt.Errorf("failed to handle event source: %w", errNotImplemented)
 	}
+
+	return false
+}
+
+// isCanceled reports whether err was caused by the cancellation of the request context,
+// i.e. because the client navigated away before the request was handled.
+func isCanceled(req *http.Request, err error) bool {
+	return errors.Is(err, context.Canceled) && errors.Is(req.Context().Err(), context.Canceled)
+}
+
+func (h *handlerImpl[T]) handleCanceledError(wr http.ResponseWriter, req *http.Request, err error) bool {
+	if !isCanceled(req, err) {
+		return false
+	}
+
+	// Walk up the tree of handlers to find the nearest CancelBoundary. Canceled
+	// requests never reach the ErrorBoundary, even if none handles the error.
+	for handler := Handler(h); handler != nil; handler = handler.GetParent() {
+		if cancelBoundary := handler.getCancelBoundary(); cancelBoundary != nil {
+			fn := cancelBoundary.CancelBoundary(wr, req)
+			if fn != nil {
+				h.logStage(req, slog.LevelDebug, "cancel_boundary", "request canceled", slog.String("boundary", fmt.Sprintf("%T", handler.getController())))
+				h.observeBoundary(req, "cancel_boundary")
+				fn(wr, req)
+				return true
+			}
+		}
+	}
+
+	h.logStage(req, slog.LevelDebug, "cancel_boundary", "request canceled")
+	return true
 }
 
 func (h *handlerImpl[T]) handleInternalError(wr http.ResponseWriter, req *http.Request, err error) bool {
 	if errors.Is(err, errNotImplemented) {
+		h.logStage(req, slog.LevelInfo, "error", "method not implemented", slog.Any("error", err), slog.Int("status", http.StatusMethodNotAllowed))
 		http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
 		return true
 	}
@@ -336,12 +482,16 @@ func (h *handlerImpl[T]) handleLoader(_ http.ResponseWriter, req *http.Request)
 		start = time.Now()
 	)
 	if h.loader != nil {
-		vm, err = h.loader.Load(req)
+		spanReq, span := h.startSpan(req, "torque.loader")
+		vm, err = h.loader.Load(spanReq)
+		endSpan(span, err)
 		if err != nil {
-			log.Printf("[Loader] %s -> error: %s\n", req.URL, err.Error())
+			if !isCanceled(req, err) {
+				h.logStage(req, slog.LevelError, "loader", "loader failed", slog.Any("error", err), slog.Duration("duration", time.Since(start)))
+			}
 			return vm, err
 		} else {
-			log.Printf("[Loader] %s -> success (%dms)\n", req.URL, time.Since(start).Milliseconds())
+			h.logStage(req, slog.LevelDebug, "loader", "loader succeeded", slog.Duration("duration", time.Since(start)))
 			return vm, nil
 		}
 	} else {
@@ -350,13 +500,16 @@ func (h *handlerImpl[T]) handleLoader(_ http.ResponseWriter, req *http.Request)
 }
 
 func (h *handlerImpl[T]) handlePanic(wr http.ResponseWriter, req *http.Request, err error) {
-	if h.panicBoundary != nil {
+	if ok := h.handleCanceledError(wr, req, err); ok {
+		return
+	} else if h.panicBoundary != nil {
 		// Calls to PanicBoundary can return an http.HandlerFunc
 		// that can be used to cleanly handle the error.
-		h := h.panicBoundary.PanicBoundary(wr, req, err)
-		if h != nil {
-			log.Printf("[PanicBoundary] %s -> handled\n", req.URL)
-			h(wr, req)
+		fn := h.panicBoundary.PanicBoundary(wr, req, err)
+		if fn != nil {
+			h.logStage(req, slog.LevelWarn, "panic_boundary", "panic handled", slog.Any("error", err))
+			h.observeBoundary(req, "panic_boundary")
+			fn(wr, req)
 			return
 		}
 	} else if h.parent != nil {
@@ -367,10 +520,11 @@ func (h *handlerImpl[T]) handlePanic(wr http.ResponseWriter, req *http.Request,
 			}
 
 			if panicBoundary := parent.GetPanicBoundary(); panicBoundary != nil {
-				h := panicBoundary.PanicBoundary(wr, req, err)
-				if h != nil {
-					log.Printf("[PanicBoundary] %s -> handled by %T\n", req.URL, parent)
-					h(wr, req)
+				fn := panicBoundary.PanicBoundary(wr, req, err)
+				if fn != nil {
+					h.logStage(req, slog.LevelWarn, "panic_boundary", "panic handled by parent", slog.Any("error", err), slog.String("boundary", fmt.Sprintf("%T", parent.getController())))
+					h.observeBoundary(req, "panic_boundary")
+					fn(wr, req)
 					return
 				}
 			}
@@ -380,21 +534,23 @@ func (h *handlerImpl[T]) handlePanic(wr http.ResponseWriter, req *http.Request,
 	}
 
 	stack := debug.Stack()
-	log.Printf("[UncaughtPanic] %s\n-- ERROR --\nUncaught panic in route controller %T: %+v\n-- STACK TRACE --\n%s", req.URL, h.ctl, err, stack)
+	h.logStage(req, slog.LevelError, "panic_boundary", "uncaught panic", slog.Any("error", err), slog.Int("status", http.StatusInternalServerError), slog.String("stack", string(stack)))
 	err = writeErrorResponse(wr, req, err, stack)
 	if err != nil {
-		log.Printf("[UncaughtPanic] %s -> failed to write error response: %v\n", req.URL, err)
+		h.logStage(req, slog.LevelError, "panic_boundary", "failed to write error response", slog.Any("error", err))
 	}
 }
 
 func (h *handlerImpl[T]) handleRenderHeaders(wr http.ResponseWriter, req *http.Request, vm T) error {
 	if h.headers != nil {
-		err := h.headers.RenderHeaders(wr, req, vm)
+		spanReq, span := h.startSpan(req, "torque.render_headers")
+		err := h.headers.RenderHeaders(wr, spanReq, vm)
+		endSpan(span, err)
 		if err != nil {
-			log.Printf("[RenderHeaders] %s -> error: %s\n", req.URL, err.Error())
+			h.logStage(req, slog.LevelError, "render_headers", "render headers failed", slog.Any("error", err))
 			return err
 		} else {
-			log.Printf("[RenderHeaders] %s -> success\n", req.URL)
+			h.logStage(req, slog.LevelDebug, "render_headers", "render headers succeeded")
 		}
 	}
 	return nil
@@ -404,7 +560,7 @@ func (h *handlerImpl[T]) handleRender(wr http.ResponseWriter, req *http.Request,
 	// If the requester set the content-type to json, we can just
 	// render the result of the loader directly
 	if req.Header.Get("Accept") == "application/json" {
-		log.Printf("[JSON] %s\n", req.URL)
+		h.logStage(req, slog.LevelDebug, "render", "rendering json")
 		encoder := json.NewEncoder(wr)
 		if UseMode(req.Context()) == ModeDevelopment {
 			encoder.SetIndent("", "  ")
@@ -412,23 +568,27 @@ func (h *handlerImpl[T]) handleRender(wr http.ResponseWriter, req *http.Request,
 		return encoder.Encode(vm)
 	}
 
+	if h.rendererT == nil && h.rendererVM == nil {
+		return errNotImplemented
+	}
+
 	var (
 		err   error
 		start = time.Now()
 	)
+	spanReq, span := h.startSpan(req, "torque.render")
 	if h.rendererT != nil {
-		err = h.rendererT.Render(wr, req, vm)
-	} else if h.rendererVM != nil {
-		err = h.rendererVM.Render(wr, req, vm)
+		err = h.rendererT.Render(wr, spanReq, vm)
 	} else {
-		return errNotImplemented
+		err = h.rendererVM.Render(wr, spanReq, vm)
 	}
+	endSpan(span, err)
 
 	if err != nil {
-		log.Printf("[Renderer] %s -> error: %s\n", req.URL, err.Error())
+		h.logStage(req, slog.LevelError, "render", "render failed", slog.Any("error", err), slog.Duration("duration", time.Since(start)))
 		return err
 	} else {
-		log.Printf("[Renderer] %s -> success (%dms)\n", req.URL, time.Since(start).Milliseconds())
+		h.logStage(req, slog.LevelDebug, "render", "render succeeded", slog.Duration("duration", time.Since(start)))
 		return nil
 	}
 }
@@ -451,7 +611,7 @@ func (h *handlerImpl[T]) handleReloadError(wr http.ResponseWriter, req *http.Req
 		req = withError(req, reloadErr.err)
 	}
 
-	log.Printf("[ReloadWithError] %s -> %s\n", req.URL, err.Error())
+	h.logStage(req, slog.LevelInfo, "action", "reloading with error", slog.Any("error", err))
 
 	req.Method = http.MethodGet
 	h.serveRequest(wr, req)
@@ -467,32 +627,48 @@ func (h *handlerImpl[T]) handleRequestHeaders(req *http.Request) (*http.Request,
 }
 
 func (h *handlerImpl[T]) handleHooks(req *http.Request) (*http.Request, error) {
-	var url = req.URL.String()
+	var orig = req
 	var err error
 	var start = time.Now()
 	if h.hookProvider != nil {
-		req, err = h.hookProvider.Hooks(req)
+		// hooks are called with the original request, so the request they return
+		// doesn't carry the context of the span after it has ended
+		_, span := h.startSpan(orig, "torque.hooks")
+		req, err = h.hookProvider.Hooks(orig)
+		endSpan(span, err)
 		if err != nil {
-			log.Printf("[Hooks] %s -> error: %s\n", url, err.Error())
-			return nil, err
+			h.logStage(orig, slog.LevelError, "hooks", "hooks failed", slog.Any("error", err), slog.Duration("duration", time.Since(start)))
+			return orig, err
 		} else {
-			log.Printf("[Hooks] %s -> success (%dms)\n", url, time.Since(start).Milliseconds())
+			h.logStage(req, slog.LevelDebug, "hooks", "hooks succeeded", slog.Duration("duration", time.Since(start)))
 		}
 	}
 	return req, nil
 }
 
 func (h *handlerImpl[T]) handlePluginSetup(_ http.ResponseWriter, req *http.Request) (*http.Request, error) {
-	var err error
+	if len(h.plugins) == 0 {
+		return req, nil
+	}
+
+	var (
+		err  error
+		orig = req
+	)
+	_, span := h.startSpan(orig, "torque.plugins")
 	for _, plugin := range h.plugins {
 		err = plugin.Setup(req)
 		if err != nil {
-			return nil, err
+			endSpan(span, err)
+			return orig, err
 		}
 		req, err = plugin.Hooks(req)
 		if err != nil {
-			return nil, err
+			endSpan(span, err)
+			return orig, err
 		}
 	}
+	span.End()
+
 	return req, nil
 }
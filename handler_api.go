@@ -11,7 +11,17 @@ type Handler interface {
 
 	getController() Controller
 	getHookProvider() HookProvider
+	getDeadlineBoundary() DeadlineBoundary
+	getCancelBoundary() CancelBoundary
+	getOptions() options
+	requestOptions(req *http.Request) options
+	setMount(Handler)
+	getMount() Handler
+	getSubscriptions() *subscriptions
 	getRouter() *router
+	getMiddlewares() []Middleware
+	getNotFound() Handler
+	getMethodNotAllowed() Handler
 
 	setPath(string)
 	GetPath() string
@@ -24,6 +34,10 @@ type Handler interface {
 	GetMode() Mode
 	HasOutlet() bool
 
+	URLFor(name string, params PathParams) (string, error)
+	Routes() []RouteInfo
+	describe() RouteInfo
+
 	SetAction(Action)
 	SetRenderer(DynamicRenderer)
 
@@ -35,6 +49,10 @@ type Handler interface {
 
 	SetPanicBoundary(PanicBoundary)
 	GetPanicBoundary() PanicBoundary
+
+	Subscribers() int
+	Broadcast(event, data string)
+	CloseSubscribers()
 }
 
 func (h *handlerImpl[T]) setOverride(override http.Handler) {
@@ -57,10 +75,30 @@ func (h *handlerImpl[T]) getHookProvider() HookProvider {
 	return h.hookProvider
 }
 
+func (h *handlerImpl[T]) getDeadlineBoundary() DeadlineBoundary {
+	return h.deadlineBoundary
+}
+
+func (h *handlerImpl[T]) getCancelBoundary() CancelBoundary {
+	return h.cancelBoundary
+}
+
 func (h *handlerImpl[T]) getRouter() *router {
 	return h.router
 }
 
+func (h *handlerImpl[T]) getMiddlewares() []Middleware {
+	return h.middlewares
+}
+
+func (h *handlerImpl[T]) getNotFound() Handler {
+	return h.notFound
+}
+
+func (h *handlerImpl[T]) getMethodNotAllowed() Handler {
+	return h.methodNotAllowed
+}
+
 func (h *handlerImpl[T]) addChild(child Handler) {
 	h.children = append(h.children, child)
 	if child.GetParent() != h {
@@ -101,6 +139,13 @@ func (h *handlerImpl[T]) HasOutlet() bool {
 	return false
 }
 
+func (h *handlerImpl[T]) URLFor(name string, params PathParams) (string, error) {
+	if h.router == nil {
+		return "", ErrRouterUndefined
+	}
+	return h.router.URLFor(name, params)
+}
+
 func (h *handlerImpl[T]) SetAction(a Action) {
 	h.action = a
 }
@@ -0,0 +1,164 @@
+package torque
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// hostRoute is a Handler registered for requests to a specific host.
+type hostRoute struct {
+	pattern string
+	labels  []hostLabel
+	handler Handler
+
+	// stacks are the middleware of the routers the host was merged up from
+	stacks []*middlewareStack
+}
+
+// hostLabel is a single dot separated label of a host pattern. Labels
+// wrapped in curly braces are captured as path parameters.
+type hostLabel struct {
+	value     string
+	paramName string
+	matcher   ParamMatcher
+}
+
+func (r *router) Host(pattern string, h http.Handler) {
+	var handler Handler
+	switch h := h.(type) {
+	case Handler:
+		handler = h
+	default:
+		handler = MustNewV(h)
+	}
+	// like any other route, the host's Handler inherits the logger and
+	// registers its EventSource subscribers with the Handler it is mounted to
+	handler.setMount(r.h)
+
+	if router := handler.getRouter(); router != nil && router.pathPolicySet {
+		r.errs = append(r.errs, fmt.Errorf("path policy of the router of host %q has no effect, it must be set by the outermost router", pattern))
+		return
+	}
+
+	var route = &hostRoute{
+		pattern: strings.ToLower(pattern),
+		handler: handler,
+	}
+	for _, label := range strings.Split(route.pattern, ".") {
+		_, paramName, constraint, isParam := parseSegment(label)
+		if !isParam {
+			route.labels = append(route.labels, hostLabel{value: label})
+			continue
+		}
+
+		matcher, err := compileParamMatcher(constraint)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid constraint in label %q of host %q: %w", label, pattern, err))
+			return
+		}
+		route.labels = append(route.labels, hostLabel{paramName: paramName, matcher: matcher})
+	}
+
+	r.addHost(route)
+}
+
+func (r *router) addHost(route *hostRoute) {
+	for _, existing := range r.hosts {
+		if existing.pattern == route.pattern {
+			r.errs = append(r.errs, fmt.Errorf("%w: host %q is already registered", ErrRouteConflict, route.pattern))
+			return
+		}
+	}
+
+	// hosts with fewer parameters are more specific and are tried first
+	r.hosts = append(r.hosts, route)
+	sort.SliceStable(r.hosts, func(i, j int) bool {
+		return r.hosts[i].paramCount() < r.hosts[j].paramCount()
+	})
+}
+
+func (route *hostRoute) paramCount() int {
+	var count = 0
+	for _, label := range route.labels {
+		if len(label.paramName) != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// match reports whether the host matches the pattern and captures any
+// parameters into params.
+func (route *hostRoute) match(host string, params PathParams) bool {
+	var labels = strings.Split(host, ".")
+	if len(labels) != len(route.labels) {
+		return false
+	}
+
+	var captured = make(PathParams)
+	for i, label := range route.labels {
+		if len(label.paramName) == 0 {
+			if label.value != labels[i] {
+				return false
+			}
+			continue
+		}
+		if len(labels[i]) == 0 || (label.matcher != nil && !label.matcher(labels[i])) {
+			return false
+		}
+		captured[label.paramName] = labels[i]
+	}
+
+	for key, value := range captured {
+		params[key] = value
+	}
+	return true
+}
+
+// matchHost returns the first host route matching the request's host and the
+// parameters captured from it, or nil if no host route matched.
+func (r *router) matchHost(req *http.Request) (*hostRoute, PathParams) {
+	if len(r.hosts) == 0 {
+		return nil, nil
+	}
+
+	var host = strings.ToLower(req.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	for _, route := range r.hosts {
+		var params = make(PathParams)
+		if route.match(host, params) {
+			return route, params
+		}
+	}
+
+	return nil, nil
+}
+
+// serveHost serves the request using the host route's Handler, wrapped with the
+// middleware of the router like any other route.
+func (r *router) serveHost(w http.ResponseWriter, req *http.Request, route *hostRoute, params PathParams) {
+	// the host's Handler routes the request with its own router
+	ctx := req.Context()
+	ctx = context.WithValue(ctx, paramsContextKey, params)
+	ctx = context.WithValue(ctx, routerMatchContextKey, false)
+	var stacks = append([]*middlewareStack{r.stack}, route.stacks...)
+	applyMiddleware(route.handler, stacks).ServeHTTP(w, req.WithContext(ctx))
+}
+
+// matchesPath reports whether the path matches a route of the host route's Handler.
+// Handlers without a router serve every path.
+func (route *hostRoute) matchesPath(method, path string) bool {
+	if router := route.handler.getRouter(); router != nil {
+		_, _, ok := router.Match(method, path)
+		return ok
+	}
+	return true
+}
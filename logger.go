@@ -0,0 +1,66 @@
+package torque
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// WithLogger sets the logger of the Handler. The logger is inherited by every Handler in
+// its route tree and its layouts, unless they were created with a logger of their own.
+// Handlers without a logger use slog.Default.
+//
+// Each stage of the request lifecycle is logged with the attributes stage, controller,
+// pattern, method, url and request_id. Successful stages are logged at the debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(opts *options) {
+		opts.logger = logger
+	}
+}
+
+// getLogger returns the logger of the options, or slog.Default if none was set.
+func (o options) getLogger() *slog.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return slog.Default()
+}
+
+// controllerName returns the type name of the Controller, or the http.Handler
+// wrapped by NewV.
+func (h *handlerImpl[T]) controllerName() string {
+	if h.ctl == nil && h.handler != nil {
+		return fmt.Sprintf("%T", h.handler)
+	}
+	return fmt.Sprintf("%T", h.ctl)
+}
+
+// logStage logs the outcome of a stage of the request lifecycle along
+// with the attributes of the Handler and the request.
+func (h *handlerImpl[T]) logStage(req *http.Request, level slog.Level, stage, msg string, attrs ...slog.Attr) {
+	var (
+		ctx    = req.Context()
+		logger = h.requestOptions(req).getLogger()
+	)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	logger.LogAttrs(ctx, level, msg, append([]slog.Attr{
+		slog.String("stage", stage),
+		slog.String("controller", h.controllerName()),
+		slog.String("pattern", routePattern(req, h.path)),
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.String("request_id", UseRequestID(req)),
+	}, attrs...)...)
+}
+
+// routePattern returns the pattern of the route matched by the router, or
+// fallback if the request was not routed.
+func routePattern(req *http.Request, fallback string) string {
+	if pattern, ok := req.Context().Value(patternContextKey).(string); ok {
+		return pattern
+	}
+	return fallback
+}
@@ -0,0 +1,147 @@
+package torque_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+// decodeLogRecords decodes the JSON log records written to buf
+func decodeLogRecords(buf *bytes.Buffer) []map[string]any {
+	var records = make([]map[string]any, 0)
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		Expect(decoder.Decode(&record)).To(Succeed())
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogger_InheritedByChildren(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	)
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/ok", torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+				LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+					return MockTemplateProvider{Message: "ok"}, nil
+				},
+			}))
+		},
+	}, torque.WithLogger(logger))
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	Expect(wr.Code).To(Equal(http.StatusOK))
+
+	var loader map[string]any
+	for _, record := range decodeLogRecords(&buf) {
+		if record["stage"] == "loader" {
+			loader = record
+		}
+	}
+	Expect(loader).NotTo(BeNil())
+	Expect(loader["level"]).To(Equal("DEBUG"))
+	Expect(loader["controller"]).To(Equal("*torque_test.MockLoader[github.com/tylermmorton/torque_test.MockTemplateProvider]"))
+	Expect(loader["pattern"]).To(Equal("/ok"))
+	Expect(loader["method"]).To(Equal(http.MethodGet))
+	Expect(loader["url"]).To(Equal("/ok"))
+	Expect(loader).To(HaveKey("duration"))
+}
+
+func TestLogger_Level(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError}))
+	)
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/ok", torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+				LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+					return MockTemplateProvider{Message: "ok"}, nil
+				},
+			}))
+			r.Handle("/fail", torque.MustNew[MockTemplateProvider](&struct {
+				MockLoader[MockTemplateProvider]
+				MockErrorBoundary
+			}{
+				MockLoader: MockLoader[MockTemplateProvider]{
+					LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+						return MockTemplateProvider{}, errors.New("failed")
+					},
+				},
+				MockErrorBoundary: mockFailingErrorBoundary,
+			}))
+		},
+	}, torque.WithLogger(logger))
+
+	RegisterTestingT(t)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	Expect(decodeLogRecords(&buf)).To(BeEmpty())
+
+	// methods that aren't implemented by the Controller are not errors
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodPost, "/ok", nil))
+	Expect(wr.Code).To(Equal(http.StatusMethodNotAllowed))
+	Expect(decodeLogRecords(&buf)).To(BeEmpty())
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	records := decodeLogRecords(&buf)
+	Expect(records).To(HaveLen(1))
+	Expect(records[0]["stage"]).To(Equal("loader"))
+	Expect(records[0]["error"]).To(Equal("failed"))
+	Expect(records[0]["pattern"]).To(Equal("/fail"))
+}
+
+func TestLogger_InheritedByLayouts(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	)
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{Message: "Hello world!"}, nil
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return createMockMessageLayout("")
+			},
+		},
+	}, torque.WithLogger(logger))
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+	Expect(wr.Body.String()).To(Equal("<div>Hello world!</div>"))
+
+	var controllers = make([]any, 0)
+	for _, record := range decodeLogRecords(&buf) {
+		if record["stage"] == "loader" {
+			controllers = append(controllers, record["controller"])
+		}
+	}
+	Expect(controllers).To(ConsistOf(
+		"*struct { torque_test.MockLoader[github.com/tylermmorton/torque_test.MockTemplateProvider]; torque_test.MockLayoutProvider }",
+		"*torque_test.MockLoader[github.com/tylermmorton/torque_test.MockMessageOutletTemplateProvider]",
+	))
+}
@@ -0,0 +1,331 @@
+package torque
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histograms
+// recorded by Metrics created with NewMetrics.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics records the requests handled by torque and exposes them in the Prometheus text
+// exposition format. It is an http.Handler, so it can be mounted to a Router:
+//
+//	metrics := torque.NewMetrics()
+//	r.Handle("/metrics", metrics)
+//
+// Every request handled by the router is recorded, including redirects and requests
+// answered with a 404 or 405. Requests that don't match any route are recorded without
+// a pattern label. The following metrics are recorded for each route pattern:
+//
+//	torque_requests_total                 counter of requests by method and status
+//	torque_request_duration_seconds       histogram of request latency by method
+//	torque_stage_duration_seconds         histogram of the latency of each stage, i.e. loader
+//	torque_boundary_hits_total            counter of errors handled by each kind of boundary
+//	torque_event_source_subscribers       gauge of the live EventSource subscribers
+//
+// A nil *Metrics doesn't record anything.
+type Metrics struct {
+	mu      sync.Mutex
+	buckets []float64
+
+	requests         map[metricLabels]uint64
+	requestDurations map[metricLabels]*histogram
+	stageDurations   map[metricLabels]*histogram
+	boundaryHits     map[metricLabels]uint64
+	subscribers      map[metricLabels]int64
+}
+
+// NewMetrics creates Metrics recording latencies using the given histogram buckets,
+// or DefaultBuckets if none are given.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	return &Metrics{
+		buckets:          buckets,
+		requests:         make(map[metricLabels]uint64),
+		requestDurations: make(map[metricLabels]*histogram),
+		stageDurations:   make(map[metricLabels]*histogram),
+		boundaryHits:     make(map[metricLabels]uint64),
+		subscribers:      make(map[metricLabels]int64),
+	}
+}
+
+// WithMetrics sets the Metrics the Handler records its requests to. Handlers in its route
+// tree and its layouts record to the same Metrics, unless they were created with their own.
+func WithMetrics(metrics *Metrics) Option {
+	return func(opts *options) {
+		opts.metrics = metrics
+	}
+}
+
+// metricLabels are the labels of a single series. Labels that don't apply to
+// a metric are left empty and are not written.
+type metricLabels struct {
+	pattern  string
+	method   string
+	status   string
+	stage    string
+	boundary string
+}
+
+func (l metricLabels) String() string {
+	var pairs = make([]string, 0, 5)
+	for _, label := range [][2]string{
+		{"pattern", l.pattern},
+		{"method", l.method},
+		{"status", l.status},
+		{"stage", l.stage},
+		{"boundary", l.boundary},
+	} {
+		if label[1] != "" {
+			pairs = append(pairs, label[0]+`="`+labelEscaper.Replace(label[1])+`"`)
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// labelEscaper escapes label values as required by the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, bound := range buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func observe(series map[metricLabels]*histogram, buckets []float64, labels metricLabels, d time.Duration) {
+	h, ok := series[labels]
+	if !ok {
+		h = &histogram{}
+		series[labels] = h
+	}
+	h.observe(buckets, d.Seconds())
+}
+
+func (m *Metrics) observeRequest(pattern, method string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[metricLabels{pattern: pattern, method: method, status: strconv.Itoa(status)}]++
+	observe(m.requestDurations, m.buckets, metricLabels{pattern: pattern, method: method}, d)
+}
+
+func (m *Metrics) observeStage(pattern, stage string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	observe(m.stageDurations, m.buckets, metricLabels{pattern: pattern, stage: stage}, d)
+}
+
+func (m *Metrics) observeBoundary(pattern, boundary string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.boundaryHits[metricLabels{pattern: pattern, boundary: boundary}]++
+}
+
+func (m *Metrics) addSubscribers(pattern string, delta int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscribers[metricLabels{pattern: pattern}] += delta
+}
+
+// ServeHTTP writes the recorded metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	wr.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	buf := bufio.NewWriter(wr)
+	m.writeTo(buf)
+	_ = buf.Flush()
+}
+
+func (m *Metrics) writeTo(wr *bufio.Writer) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeCounters(wr, "torque_requests_total", "Total number of requests handled.", m.requests)
+	writeHistograms(wr, "torque_request_duration_seconds", "Latency of handled requests in seconds.", m.buckets, m.requestDurations)
+	writeHistograms(wr, "torque_stage_duration_seconds", "Latency of each stage of the request lifecycle in seconds.", m.buckets, m.stageDurations)
+	writeCounters(wr, "torque_boundary_hits_total", "Total number of errors handled by a boundary.", m.boundaryHits)
+
+	fmt.Fprintf(wr, "# HELP torque_event_source_subscribers Number of live EventSource subscribers.\n")
+	fmt.Fprintf(wr, "# TYPE torque_event_source_subscribers gauge\n")
+	for _, labels := range sortedLabels(m.subscribers) {
+		fmt.Fprintf(wr, "torque_event_source_subscribers{%s} %d\n", labels, m.subscribers[labels])
+	}
+}
+
+func writeCounters(wr *bufio.Writer, name, help string, series map[metricLabels]uint64) {
+	fmt.Fprintf(wr, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(wr, "# TYPE %s counter\n", name)
+	for _, labels := range sortedLabels(series) {
+		fmt.Fprintf(wr, "%s{%s} %d\n", name, labels, series[labels])
+	}
+}
+
+func writeHistograms(wr *bufio.Writer, name, help string, buckets []float64, series map[metricLabels]*histogram) {
+	fmt.Fprintf(wr, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(wr, "# TYPE %s histogram\n", name)
+	for _, labels := range sortedLabels(series) {
+		var h = series[labels]
+		for i, bound := range buckets {
+			fmt.Fprintf(wr, "%s_bucket{%s,le=%q} %d\n", name, labels, formatFloat(bound), h.counts[i])
+		}
+		fmt.Fprintf(wr, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(wr, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(wr, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+func sortedLabels[V any](series map[metricLabels]V) []metricLabels {
+	var labels = make([]metricLabels, 0, len(series))
+	for l := range series {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].String() < labels[j].String()
+	})
+	return labels
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// observeBoundary records an error handled by the given kind of boundary.
+func (h *handlerImpl[T]) observeBoundary(req *http.Request, boundary string) {
+	h.requestOptions(req).metrics.observeBoundary(routePattern(req, h.path), boundary)
+}
+
+// metricsResponseWriter records the status code of the response and the pattern of the
+// route that served the request.
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	pattern string
+}
+
+func (w *metricsResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *metricsResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush allows streamed responses, such as outlets and event sources, to be recorded.
+func (w *metricsResponseWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *metricsResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withRequestMetrics records the request to the Metrics once the returned func is called.
+// Requests are only recorded once, by the outermost router or Handler serving them, with
+// the pattern of the route set by setMetricsPattern.
+func withRequestMetrics(wr http.ResponseWriter, req *http.Request, metrics *Metrics, pattern string) (http.ResponseWriter, *http.Request, func()) {
+	if metrics == nil {
+		return wr, req, func() {}
+	} else if _, ok := req.Context().Value(metricsRecorderContextKey).(*metricsResponseWriter); ok {
+		return wr, req, func() {}
+	}
+
+	var (
+		start    = time.Now()
+		method   = metricsMethod(req.Method)
+		recorder = &metricsResponseWriter{ResponseWriter: wr, pattern: pattern}
+	)
+	return recorder, With(req, metricsRecorderContextKey, recorder), func() {
+		var status = recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		metrics.observeRequest(recorder.pattern, method, status, time.Since(start))
+	}
+}
+
+// setMetricsPattern sets the pattern the request is recorded with once it was matched.
+func setMetricsPattern(req *http.Request, pattern string) {
+	if recorder, ok := req.Context().Value(metricsRecorderContextKey).(*metricsResponseWriter); ok {
+		recorder.pattern = pattern
+	}
+}
+
+// withRequestMetrics records requests served by a Handler without a router. Layouts
+// rendered as part of an outlet chain are not recorded as requests.
+func (h *handlerImpl[T]) withRequestMetrics(wr http.ResponseWriter, req *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	if _, ok := req.Context().Value(outletContextKey).(outletMarker); ok {
+		return wr, req, func() {}
+	}
+	return withRequestMetrics(wr, req, h.requestOptions(req).metrics, routePattern(req, h.path))
+}
+
+// metricsMethod returns the method label of the request. Methods not defined by
+// RFC 9110 are recorded as OTHER, so clients can't create unbounded series.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
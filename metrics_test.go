@@ -0,0 +1,155 @@
+package torque_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockEventSource struct {
+	SubscribeFunc func(wr http.ResponseWriter, req *http.Request) error
+}
+
+func (m MockEventSource) Subscribe(wr http.ResponseWriter, req *http.Request) error {
+	return m.SubscribeFunc(wr, req)
+}
+
+var _ torque.EventSource = MockEventSource{}
+
+// scrapeMetrics returns the lines written by the metrics handler mounted at /metrics
+func scrapeMetrics(h http.Handler) []string {
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Header().Get("Content-Type")).To(HavePrefix("text/plain; version=0.0.4"))
+	return strings.Split(wr.Body.String(), "\n")
+}
+
+func TestMetrics_Requests(t *testing.T) {
+	var metrics = torque.NewMetrics()
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/metrics", metrics)
+			r.Handle("/users/{id}", torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+				LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+					return MockTemplateProvider{Message: "ok"}, nil
+				},
+			}))
+			r.Handle("/fail", torque.MustNew[MockTemplateProvider](&struct {
+				MockLoader[MockTemplateProvider]
+				MockErrorBoundary
+			}{
+				MockLoader: MockLoader[MockTemplateProvider]{
+					LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+						return MockTemplateProvider{}, errors.New("failed")
+					},
+				},
+				MockErrorBoundary: mockFailingErrorBoundary,
+			}))
+		},
+	}, torque.WithMetrics(metrics))
+
+	RegisterTestingT(t)
+	for _, path := range []string{"/users/1", "/users/2", "/fail"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	lines := scrapeMetrics(h)
+	Expect(lines).To(ContainElements(
+		"# TYPE torque_requests_total counter",
+		`torque_requests_total{pattern="/users/{id}",method="GET",status="200"} 2`,
+		`torque_requests_total{pattern="/fail",method="GET",status="500"} 1`,
+		`torque_request_duration_seconds_bucket{pattern="/users/{id}",method="GET",le="+Inf"} 2`,
+		`torque_request_duration_seconds_count{pattern="/users/{id}",method="GET"} 2`,
+		`torque_stage_duration_seconds_count{pattern="/users/{id}",stage="loader"} 2`,
+		`torque_stage_duration_seconds_count{pattern="/users/{id}",stage="render"} 2`,
+		`torque_stage_duration_seconds_count{pattern="/fail",stage="loader"} 1`,
+		`torque_boundary_hits_total{pattern="/fail",boundary="error_boundary"} 1`,
+	))
+}
+
+func TestMetrics_Subscribers(t *testing.T) {
+	var (
+		metrics     = torque.NewMetrics()
+		subscribed  = make(chan struct{})
+		unsubscribe = make(chan struct{})
+	)
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/metrics", metrics)
+			r.Handle("/events", torque.MustNew[any](&MockEventSource{
+				SubscribeFunc: func(wr http.ResponseWriter, req *http.Request) error {
+					subscribed <- struct{}{}
+					<-unsubscribe
+					return nil
+				},
+			}))
+		},
+	}, torque.WithMetrics(metrics))
+
+	RegisterTestingT(t)
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodGet, "/events", nil)
+		req.Header.Set("Accept", "text/event-stream")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	<-subscribed
+	Expect(scrapeMetrics(h)).To(ContainElement(`torque_event_source_subscribers{pattern="/events"} 1`))
+
+	close(unsubscribe)
+	<-done
+	Expect(scrapeMetrics(h)).To(ContainElement(`torque_event_source_subscribers{pattern="/events"} 0`))
+}
+
+func TestMetrics_Requests_Router(t *testing.T) {
+	var metrics = torque.NewMetrics()
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/metrics", metrics)
+			r.Get("/plain", torque.NoOutlet(http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				wr.WriteHeader(http.StatusAccepted)
+			})))
+		},
+	}, torque.WithMetrics(metrics))
+
+	RegisterTestingT(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/plain", nil),
+		httptest.NewRequest(http.MethodPost, "/plain", nil),
+		httptest.NewRequest(http.MethodGet, "/missing", nil),
+		httptest.NewRequest("PROPFIND", "/plain", nil),
+		httptest.NewRequest("BREW", "/plain", nil),
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := scrapeMetrics(h)
+	Expect(lines).To(ContainElements(
+		`torque_requests_total{pattern="/plain",method="GET",status="202"} 1`,
+		`torque_requests_total{pattern="/plain",method="POST",status="405"} 1`,
+		`torque_requests_total{method="GET",status="404"} 1`,
+		`torque_requests_total{pattern="/plain",method="OTHER",status="405"} 2`,
+	))
+	for _, line := range lines {
+		Expect(line).ToNot(ContainSubstring("PROPFIND"))
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var metrics *torque.Metrics
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	metrics.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(BeEmpty())
+}
@@ -5,7 +5,7 @@ import (
 	"net/http"
 )
 
-func New[T ViewModel](ctl Controller) (Handler, error) {
+func New[T ViewModel](ctl Controller, opts ...Option) (Handler, error) {
 	var (
 		// vm is the zero value of the generic constraint that
 		// can be used in type assertions
@@ -14,6 +14,7 @@ func New[T ViewModel](ctl Controller) (Handler, error) {
 	)
 	h := createHandlerImpl[T]()
 	h.ctl = ctl
+	h.applyOptions(opts)
 
 	err = assertImplementations(h, ctl, vm)
 	if err != nil {
@@ -30,8 +31,8 @@ func New[T ViewModel](ctl Controller) (Handler, error) {
 	return h, nil
 }
 
-func MustNew[T ViewModel](ctl Controller) Handler {
-	h, err := New[T](ctl)
+func MustNew[T ViewModel](ctl Controller, opts ...Option) Handler {
+	h, err := New[T](ctl, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -45,9 +46,10 @@ func MustNew[T ViewModel](ctl Controller) Handler {
 // It also enables parts of the Controller API including PluginProvider,
 // GuardProvider and PanicBoundary. These interfaces can be implemented
 // on the given http.Handler to provide additional functionality.
-func NewV(handler http.Handler) (Handler, error) {
+func NewV(handler http.Handler, opts ...Option) (Handler, error) {
 	h := createHandlerImpl[any]()
 	h.handler = handler
+	h.applyOptions(opts)
 
 	// If the passed handler is actually an http.HandlerFunc it can't possibly
 	// implement any of the torque Controller interfaces.
@@ -65,8 +67,8 @@ func NewV(handler http.Handler) (Handler, error) {
 	return h, nil
 }
 
-func MustNewV(handler http.Handler) Handler {
-	h, err := NewV(handler)
+func MustNewV(handler http.Handler, opts ...Option) Handler {
+	h, err := NewV(handler, opts...)
 	if err != nil {
 		panic(err)
 	}
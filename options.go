@@ -0,0 +1,65 @@
+package torque
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Option configures a Handler created by New or NewV.
+type Option func(opts *options)
+
+// options are inherited by every Handler in the route tree of the Handler they are set
+// on, and by its layouts, unless the Handler was created with options of its own.
+type options struct {
+	logger  *slog.Logger
+	tracer  Tracer
+	metrics *Metrics
+}
+
+// inherit returns the options with every option that isn't set taken from parent.
+func (o options) inherit(parent options) options {
+	if o.logger == nil {
+		o.logger = parent.logger
+	}
+	if o.tracer == nil {
+		o.tracer = parent.tracer
+	}
+	if o.metrics == nil {
+		o.metrics = parent.metrics
+	}
+	return o
+}
+
+func (h *handlerImpl[T]) applyOptions(opts []Option) {
+	var o = options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h.opts = o
+}
+
+// getOptions returns the options of the Handler, inheriting those that aren't set
+// from the Handler it is mounted to.
+func (h *handlerImpl[T]) getOptions() options {
+	if h.mount != nil {
+		return h.opts.inherit(h.mount.getOptions())
+	}
+	return h.opts
+}
+
+func (h *handlerImpl[T]) setMount(mount Handler) {
+	h.mount = mount
+}
+
+// requestOptions returns the options used to handle the request. Handlers that aren't
+// mounted to a router, such as layouts and NotFound handlers, inherit the options of the
+// handler serving the request through the request context.
+func (h *handlerImpl[T]) requestOptions(req *http.Request) options {
+	var opts = h.getOptions()
+	if req != nil {
+		if inherited, ok := req.Context().Value(optionsContextKey).(options); ok {
+			opts = opts.inherit(inherited)
+		}
+	}
+	return opts
+}
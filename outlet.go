@@ -0,0 +1,201 @@
+package torque
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"html/template"
+	"log/slog"
+	"net/http"
+)
+
+// outletMarker is rendered by a layout's {{outlet}} in place of the child's content. The
+// layout's output is then split at the marker, so it never has to be parsed as a template.
+type outletMarker string
+
+func newOutletMarker() outletMarker {
+	var b = make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return outletMarker("<!--torque-outlet:" + hex.EncodeToString(b) + "-->")
+}
+
+// withParentContext returns the context used to render the parent of the Handler. It carries
+// the outlet marker and the Handler's options, which are inherited by the parent.
+func (h *handlerImpl[T]) withParentContext(req *http.Request, marker outletMarker) context.Context {
+	ctx := context.WithValue(req.Context(), outletContextKey, marker)
+	return context.WithValue(ctx, optionsContextKey, h.requestOptions(req))
+}
+
+// outletFunc returns the template func rendering the outlet marker of the request. Layouts
+// rendered on their own don't have a marker and render an empty outlet.
+func outletFunc(req *http.Request) func() template.HTML {
+	return func() template.HTML {
+		if req == nil {
+			return ""
+		}
+		marker, _ := req.Context().Value(outletContextKey).(outletMarker)
+		return template.HTML(marker)
+	}
+}
+
+// splitOutlet splits the output of a layout into the content rendered before and
+// after the outlet marker.
+func splitOutlet(byt []byte, marker outletMarker) (head, tail []byte) {
+	head, tail, _ = bytes.Cut(byt, []byte(marker))
+	return head, tail
+}
+
+// responseBuffer is an http.ResponseWriter buffering the response of a handler
+// rendered as part of an outlet chain.
+type responseBuffer struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: make(http.Header), code: http.StatusOK}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) Write(byt []byte) (int, error) {
+	return b.body.Write(byt)
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	b.code = code
+}
+
+// copyHeaders copies the headers of the buffered response to wr.
+func (b *responseBuffer) copyHeaders(wr http.ResponseWriter) {
+	for key, values := range b.header {
+		wr.Header()[key] = append([]string(nil), values...)
+	}
+}
+
+// writeTo writes the buffered response to wr as is.
+func (b *responseBuffer) writeTo(wr http.ResponseWriter) {
+	b.copyHeaders(wr)
+	wr.WriteHeader(b.code)
+	if _, err := wr.Write(b.body.Bytes()); err != nil {
+		panic(err)
+	}
+}
+
+// serveOutlet renders the Handler within the outlet of its parent. The child's hooks and guards
+// are executed first, because they can pass context to the parent or reject the request. Afterwards
+// the child and the parent are handled concurrently, so the loaders of an entire layout chain run
+// in parallel.
+//
+// serveOutlet returns true if the child was rendered within the outlet of its parent.
+func (h *handlerImpl[T]) serveOutlet(wr http.ResponseWriter, req *http.Request) bool {
+	var (
+		marker     = newOutletMarker()
+		childResp  = newResponseBuffer()
+		parentResp = newResponseBuffer()
+	)
+
+	childReq := h.prepareRequest(childResp, req)
+	if childReq == nil {
+		// the request was handled by a guard or boundary, the
+		// parent is never rendered for a rejected request
+		childResp.writeTo(wr)
+		return false
+	} else if h.outletStreamer != nil && h.outletStreamer.StreamOutlet(childReq) {
+		return h.streamOutlet(wr, childReq, childResp)
+	}
+	wait := h.handleRequestAsync(childResp, childReq)
+
+	// pass the childReq context here, because it might have been modified by hooks
+	h.GetParent().ServeHTTP(parentResp, childReq.Clone(h.withParentContext(childReq, marker)))
+	wait()
+
+	if childResp.code != http.StatusOK {
+		// child route is indicating a non-200 error code, do not
+		// render as outlet, maybe it's a redirect
+		childResp.writeTo(wr)
+		return false
+	} else if parentResp.code != http.StatusOK {
+		parentResp.writeTo(wr)
+		return false
+	}
+	head, tail := splitOutlet(parentResp.body.Bytes(), marker)
+
+	childResp.copyHeaders(wr)
+	parentResp.copyHeaders(wr)
+	wr.WriteHeader(http.StatusOK)
+	for _, byt := range [][]byte{head, childResp.body.Bytes(), tail} {
+		if _, err := wr.Write(byt); err != nil {
+			panic(err)
+		}
+	}
+
+	return true
+}
+
+// streamOutlet is the same as serveOutlet, but flushes everything the parent rendered before
+// the outlet to the client as soon as it is available. Only the headers set while preparing
+// the child request can be sent along with it.
+func (h *handlerImpl[T]) streamOutlet(wr http.ResponseWriter, childReq *http.Request, headerResp *responseBuffer) bool {
+	var (
+		marker     = newOutletMarker()
+		childResp  = newResponseBuffer()
+		parentResp = newResponseBuffer()
+	)
+
+	wait := h.handleRequestAsync(childResp, childReq)
+	h.GetParent().ServeHTTP(parentResp, childReq.Clone(h.withParentContext(childReq, marker)))
+	if parentResp.code != http.StatusOK {
+		// the parent is indicating a non-200 error code, the child
+		// can't be rendered within it
+		wait()
+		parentResp.writeTo(wr)
+		return false
+	}
+	head, tail := splitOutlet(parentResp.body.Bytes(), marker)
+
+	headerResp.copyHeaders(wr)
+	parentResp.copyHeaders(wr)
+	wr.WriteHeader(http.StatusOK)
+	if _, err := wr.Write(head); err != nil {
+		panic(err)
+	}
+	if flusher, ok := wr.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	wait()
+	if childResp.code != http.StatusOK {
+		h.logStage(childReq, slog.LevelWarn, "outlet", "status discarded after the layout was streamed", slog.Int("status", childResp.code))
+	}
+
+	for _, byt := range [][]byte{childResp.body.Bytes(), tail} {
+		if _, err := wr.Write(byt); err != nil {
+			panic(err)
+		}
+	}
+
+	return true
+}
+
+// handleRequestAsync handles the request in a new goroutine. The returned func waits for the
+// request to be handled and re-panics on the calling goroutine if the handler panicked.
+func (h *handlerImpl[T]) handleRequestAsync(wr http.ResponseWriter, req *http.Request) (wait func()) {
+	var done = make(chan any, 1)
+	go func() {
+		defer func() { done <- recover() }()
+		h.handleRequest(wr, req)
+	}()
+
+	return func() {
+		if p := <-done; p != nil {
+			panic(p)
+		}
+	}
+}
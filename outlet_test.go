@@ -0,0 +1,323 @@
+package torque_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockMessageOutletTemplateProvider struct {
+	Message string
+}
+
+func (MockMessageOutletTemplateProvider) TemplateText() string {
+	return "<div>{{ .Message }}{{ outlet }}</div>"
+}
+
+type MockOutletStreamer struct {
+	StreamOutletFunc func(req *http.Request) bool
+}
+
+func (m MockOutletStreamer) StreamOutlet(req *http.Request) bool {
+	return m.StreamOutletFunc(req)
+}
+
+func createMockMessageLayout(message string) torque.Handler {
+	return torque.MustNew[MockMessageOutletTemplateProvider](&MockLoader[MockMessageOutletTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockMessageOutletTemplateProvider, error) {
+			return MockMessageOutletTemplateProvider{Message: message}, nil
+		},
+	})
+}
+
+func TestOutlet_LayoutOutputIsNotParsed(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{Message: "{{ .Child }}"}, nil
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return createMockMessageLayout("{{ .Layout }}")
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(wr, req)
+
+	res := wr.Result()
+	defer Expect(res.Body.Close()).To(BeNil())
+	byt, err := io.ReadAll(res.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(res.StatusCode).To(Equal(http.StatusOK))
+	Expect(string(byt)).To(Equal("<div>{{ .Layout }}{{ .Child }}</div>"))
+}
+
+func TestOutlet_LayoutWithoutChild(t *testing.T) {
+	h := createMockMessageLayout("Hello")
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(Equal("<div>Hello</div>"))
+}
+
+// flushNotifier is a ResponseRecorder that notifies when the response is first flushed
+type flushNotifier struct {
+	*httptest.ResponseRecorder
+	once    sync.Once
+	flushed chan struct{}
+}
+
+func (f *flushNotifier) Flush() {
+	f.ResponseRecorder.Flush()
+	f.once.Do(func() { close(f.flushed) })
+}
+
+func TestOutlet_Streaming(t *testing.T) {
+	var wr = &flushNotifier{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan struct{})}
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+		MockOutletStreamer
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				// the layout head must be flushed before the Loader finishes
+				select {
+				case <-wr.flushed:
+					return MockTemplateProvider{Message: "world!"}, nil
+				case <-time.After(time.Second):
+					return MockTemplateProvider{Message: "timeout"}, nil
+				}
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return createMockMessageLayout("Hello ")
+			},
+		},
+		MockOutletStreamer: MockOutletStreamer{
+			StreamOutletFunc: func(req *http.Request) bool { return true },
+		},
+	})
+
+	RegisterTestingT(t)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(Equal("<div>Hello world!</div>"))
+}
+
+func TestOutlet_StreamingDisabled(t *testing.T) {
+	var wr = httptest.NewRecorder()
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+		MockOutletStreamer
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{}, torque.RedirectError("/login", http.StatusFound)
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return createMockMessageLayout("Hello ")
+			},
+		},
+		MockOutletStreamer: MockOutletStreamer{
+			StreamOutletFunc: func(req *http.Request) bool { return false },
+		},
+	})
+
+	RegisterTestingT(t)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusFound))
+	Expect(wr.Header().Get("Location")).To(Equal("/login"))
+}
+
+type MockGuardProvider struct {
+	GuardsFunc func() []torque.Guard
+}
+
+func (m MockGuardProvider) Guards() []torque.Guard {
+	return m.GuardsFunc()
+}
+
+func TestOutlet_Guard(t *testing.T) {
+	var parentLoads atomic.Int32
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+		MockGuardProvider
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{Message: "secret"}, nil
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return torque.MustNew[MockMessageOutletTemplateProvider](&MockLoader[MockMessageOutletTemplateProvider]{
+					LoadFunc: func(req *http.Request) (MockMessageOutletTemplateProvider, error) {
+						parentLoads.Add(1)
+						return MockMessageOutletTemplateProvider{Message: "Hello "}, nil
+					},
+				})
+			},
+		},
+		MockGuardProvider: MockGuardProvider{
+			GuardsFunc: func() []torque.Guard {
+				return []torque.Guard{
+					func(req *http.Request) http.HandlerFunc {
+						return func(wr http.ResponseWriter, req *http.Request) {
+							http.Redirect(wr, req, "/login", http.StatusFound)
+						}
+					},
+				}
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Expect(wr.Code).To(Equal(http.StatusFound))
+	Expect(wr.Header().Get("Location")).To(Equal("/login"))
+	Expect(parentLoads.Load()).To(BeZero())
+}
+
+func TestOutlet_ParallelLoaders(t *testing.T) {
+	// each Loader waits for the other one to start, which
+	// only succeeds if they are executed concurrently
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	var await = func(message string) (string, error) {
+		barrier.Done()
+		var done = make(chan struct{})
+		go func() {
+			barrier.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return message, nil
+		case <-time.After(time.Second):
+			return "", errors.New("loaders were not executed concurrently")
+		}
+	}
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				message, err := await("world!")
+				return MockTemplateProvider{Message: message}, err
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return torque.MustNew[MockMessageOutletTemplateProvider](&MockLoader[MockMessageOutletTemplateProvider]{
+					LoadFunc: func(req *http.Request) (MockMessageOutletTemplateProvider, error) {
+						message, err := await("Hello ")
+						return MockMessageOutletTemplateProvider{Message: message}, err
+					},
+				})
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(Equal("<div>Hello world!</div>"))
+}
+
+type MockErrorBoundary struct {
+	ErrorBoundaryFunc func(wr http.ResponseWriter, req *http.Request, err error) http.HandlerFunc
+}
+
+func (m MockErrorBoundary) ErrorBoundary(wr http.ResponseWriter, req *http.Request, err error) http.HandlerFunc {
+	return m.ErrorBoundaryFunc(wr, req, err)
+}
+
+func TestOutlet_ParallelLoaders_ErrorBoundary(t *testing.T) {
+	var errorBoundary = func(message string) MockErrorBoundary {
+		return MockErrorBoundary{
+			ErrorBoundaryFunc: func(wr http.ResponseWriter, req *http.Request, err error) http.HandlerFunc {
+				return func(wr http.ResponseWriter, req *http.Request) {
+					_, _ = wr.Write([]byte(message + ": " + err.Error()))
+				}
+			},
+		}
+	}
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+		MockErrorBoundary
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{}, errors.New("child failed")
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return torque.MustNew[MockMessageOutletTemplateProvider](&struct {
+					MockLoader[MockMessageOutletTemplateProvider]
+					MockErrorBoundary
+				}{
+					MockLoader: MockLoader[MockMessageOutletTemplateProvider]{
+						LoadFunc: func(req *http.Request) (MockMessageOutletTemplateProvider, error) {
+							return MockMessageOutletTemplateProvider{Message: "Hello "}, nil
+						},
+					},
+					MockErrorBoundary: errorBoundary("layout"),
+				})
+			},
+		},
+		MockErrorBoundary: errorBoundary("child"),
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(Equal("<div>Hello child: child failed</div>"))
+}
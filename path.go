@@ -3,8 +3,9 @@ package torque
 import (
 	"net/http"
 	"net/url"
-
-	"github.com/pkg/errors"
+	"regexp"
+	"strconv"
+	"sync"
 )
 
 type PathParams map[string]string
@@ -18,6 +19,53 @@ func (m PathParams) Values() url.Values {
 	return res
 }
 
+// ParamMatcher reports whether a path segment satisfies a path parameter
+// constraint, such as {id:int}.
+type ParamMatcher func(segment string) bool
+
+var (
+	paramMatchersMu sync.RWMutex
+	paramMatchers   = map[string]ParamMatcher{
+		"int": func(segment string) bool {
+			_, err := strconv.ParseInt(segment, 10, 64)
+			return err == nil
+		},
+		"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+	}
+)
+
+// RegisterParamMatcher registers a named path parameter constraint that can be
+// referenced from route patterns, i.e. RegisterParamMatcher("slug", ...) enables
+// the pattern /posts/{slug:slug}. Matchers must be registered before the routes
+// using them are created.
+func RegisterParamMatcher(name string, matcher ParamMatcher) {
+	paramMatchersMu.Lock()
+	defer paramMatchersMu.Unlock()
+	paramMatchers[name] = matcher
+}
+
+// compileParamMatcher resolves the constraint of a path parameter to a
+// ParamMatcher. Constraints that are not the name of a registered matcher
+// are compiled as a regular expression that must match the entire segment.
+func compileParamMatcher(constraint string) (ParamMatcher, error) {
+	if len(constraint) == 0 {
+		return nil, nil
+	}
+
+	paramMatchersMu.RLock()
+	matcher, ok := paramMatchers[constraint]
+	paramMatchersMu.RUnlock()
+	if ok {
+		return matcher, nil
+	}
+
+	re, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
 func GetPathParam(req *http.Request, key string) string {
 	if params, ok := req.Context().Value(paramsContextKey).(PathParams); ok {
 		if val, exists := params[key]; exists {
@@ -27,31 +75,50 @@ func GetPathParam(req *http.Request, key string) string {
 	return ""
 }
 
+// DecodePathParams decodes the path parameters of the request into a new T,
+// applying the ValidateTag rules of its fields.
 func DecodePathParams[T any](req *http.Request) (*T, error) {
-	d, ok := UseDecoder(req)
-	if !ok {
-		return nil, ErrDecoderUndefined
+	res, err := decodePathParams[T](req)
+	if err != nil {
+		return nil, err
 	}
 
-	var dst T
-	if params, ok := req.Context().Value(paramsContextKey).(PathParams); ok {
-		err := d.Decode(&dst, params.Values())
-		if err != nil {
-			return nil, err
-		}
+	if err := validateDecoded(req.Context(), res, nil, ErrPathParamValidationFailure); err != nil {
+		return nil, err
 	}
-	return &dst, nil
+
+	return res, nil
 }
 
+// DecodeAndValidatePathParams decodes the path parameters of the request like
+// DecodePathParams and validates them using the Validate method of T.
 func DecodeAndValidatePathParams[T SelfValidator](req *http.Request) (*T, error) {
-	res, err := DecodePathParams[T](req)
+	res, err := decodePathParams[T](req)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := (*res).Validate(req.Context()); err != nil {
-		return nil, errors.Wrap(ErrPathParamValidationFailure, err.Error())
+	if err := validateDecoded(req.Context(), res, *res, ErrPathParamValidationFailure); err != nil {
+		return nil, err
 	}
 
 	return res, nil
 }
+
+func decodePathParams[T any](req *http.Request) (*T, error) {
+	d, ok := UseDecoder(req)
+	if !ok {
+		return nil, ErrDecoderUndefined
+	}
+
+	var dst T
+	if params, ok := req.Context().Value(paramsContextKey).(PathParams); ok {
+		err := d.Decode(&dst, params.Values())
+		if errs, ok := fromSchemaError(err); ok {
+			return nil, &validationError{ErrPathParamDecodeFailure, errs}
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return &dst, nil
+}
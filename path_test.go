@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -79,3 +80,81 @@ func TestPath_GetPathParam_VanillaHandler(t *testing.T) {
 	Expect(res.StatusCode).To(Equal(http.StatusOK))
 	Expect(string(byt)).To(Equal("hello, tommy!"))
 }
+
+func TestPath_ParamConstraints(t *testing.T) {
+	torque.RegisterParamMatcher("even", func(segment string) bool {
+		n, err := strconv.Atoi(segment)
+		return err == nil && n%2 == 0
+	})
+
+	var writeRoute = func(name string) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			_, err := wr.Write([]byte(fmt.Sprintf("%s:%s", name, torque.GetPathParam(req, "id"))))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	}
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/users/new", writeRoute("new"))
+			r.Handle("/users/{id:int}", writeRoute("int"))
+			r.Handle("/users/{id:[a-z]+}", writeRoute("regex"))
+			r.Handle("/users/{id}", writeRoute("any"))
+			r.Handle("/items/{id:even}", writeRoute("even"))
+			r.Handle("/uuids/{id:uuid}", writeRoute("uuid"))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for path, expected := range map[string]string{
+		"/users/new":   "new:",
+		"/users/42":    "int:42",
+		"/users/tommy": "regex:tommy",
+		"/users/a-b_c": "any:a-b_c",
+		"/items/4":     "even:4",
+		"/uuids/4b8d2cf1-3f7a-4c66-9a0e-1d2f3b4c5d6e": "uuid:4b8d2cf1-3f7a-4c66-9a0e-1d2f3b4c5d6e",
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		h.ServeHTTP(wr, req)
+
+		res := wr.Result()
+		byt, err := io.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Body.Close()).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusOK), path)
+		Expect(string(byt)).To(Equal(expected), path)
+	}
+
+	for _, path := range []string{"/items/3", "/uuids/42"} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		h.ServeHTTP(wr, req)
+		Expect(wr.Code).To(Equal(http.StatusNotFound), path)
+	}
+}
+
+func TestPath_ParamConstraints_Fallthrough(t *testing.T) {
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/posts/{id:int}/comments", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				_, err := wr.Write([]byte("comments"))
+				Expect(err).NotTo(HaveOccurred())
+			}))
+			r.Handle("/posts/{slug}/edit", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				_, err := wr.Write([]byte("edit " + torque.GetPathParam(req, "slug")))
+				Expect(err).NotTo(HaveOccurred())
+			}))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/posts/42/edit", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(Equal("edit 42"))
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+
+	"github.com/tylermmorton/torque"
 )
 
 var (
@@ -25,6 +27,10 @@ type SourceFunc func(chan string)
 type EventSourceMap map[EventKey]SourceFunc
 
 // SSE creates a handler that is adapted to htmx's sse extension.
+//
+// When the torque.Server serving the request shuts down, SSE sends a final
+// torque.ShutdownEvent and returns. Use it with the sse-close attribute to
+// close the stream on the client.
 func SSE(wr http.ResponseWriter, req *http.Request, sources EventSourceMap) error {
 	loo, ok := wr.(http.Flusher)
 	if !ok {
@@ -37,6 +43,7 @@ func SSE(wr http.ResponseWriter, req *http.Request, sources EventSourceMap) erro
 	wr.WriteHeader(http.StatusOK)
 
 	loo.Flush()
+	shutdown := torque.UseShutdown(req)
 	mu := sync.Mutex{}
 	wg := sync.WaitGroup{}
 	for key, fn := range sources {
@@ -54,6 +61,9 @@ func SSE(wr http.ResponseWriter, req *http.Request, sources EventSourceMap) erro
 				case <-req.Context().Done():
 					return
 
+				case <-shutdown:
+					return
+
 				case data, ok := <-ch:
 					if !ok {
 						return
@@ -93,5 +103,15 @@ func SSE(wr http.ResponseWriter, req *http.Request, sources EventSourceMap) erro
 		}(&mu, &wg, key, fn)
 	}
 	wg.Wait()
+
+	select {
+	case <-shutdown:
+		_, err := wr.Write([]byte("event: " + torque.ShutdownEvent + "\ndata: \n\n"))
+		if err != nil {
+			return err
+		}
+		loo.Flush()
+	default:
+	}
 	return nil
 }
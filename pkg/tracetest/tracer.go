@@ -0,0 +1,121 @@
+// Package tracetest provides an in-memory torque.Tracer that records
+// spans, so they can be inspected in tests.
+package tracetest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tylermmorton/torque"
+)
+
+type spanContextKey struct{}
+
+// Tracer is a torque.Tracer that records every span it starts.
+type Tracer struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, torque.Span) {
+	parent, _ := ctx.Value(spanContextKey{}).(*Span)
+
+	span := &Span{
+		Name:       name,
+		Parent:     parent,
+		Attributes: attrs,
+		StartTime:  time.Now(),
+	}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// Spans returns the spans started by the Tracer, in order.
+func (t *Tracer) Spans() []*Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*Span{}, t.spans...)
+}
+
+// Find returns the first span with the given name, or nil if there is none.
+func (t *Tracer) Find(name string) *Span {
+	for _, span := range t.Spans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// Span is a span recorded by the Tracer.
+type Span struct {
+	mu sync.Mutex
+
+	Name       string
+	Parent     *Span
+	Attributes []slog.Attr
+	Err        error
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+func (s *Span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Err = err
+}
+
+func (s *Span) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.EndTime = time.Now()
+}
+
+// Attr returns the value of the attribute with the given key.
+func (s *Span) Attr(key string) (slog.Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, attr := range s.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// Ended reports whether End was called on the span.
+func (s *Span) Ended() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.EndTime.IsZero()
+}
+
+// Duration returns the time between the start and end of the span.
+func (s *Span) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.EndTime.Sub(s.StartTime)
+}
@@ -0,0 +1,193 @@
+package torque
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader is the header used to correlate a request with the problem details
+// of its error response. If the client doesn't send one, an ID is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// Problem is an error response in the application/problem+json format, as described by
+// RFC 7807. It is written for errors returned from the Controller API that aren't handled
+// by an ErrorBoundary, when the client accepts JSON.
+//
+// Problems are production-safe: the details of errors without a status code, i.e. the
+// error of a panic, are only included in development mode.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// RequestID correlates the problem with the request, i.e. in logs.
+	RequestID string `json:"requestId,omitempty"`
+	// InvalidParams are the validation failures of the decoded form, query or path parameters.
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+	// Extensions are additional members of the problem.
+	Extensions map[string]any `json:"-"`
+}
+
+// InvalidParam describes a single validation failure. Name is empty if the
+// failure doesn't relate to a single field.
+type InvalidParam struct {
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// MarshalJSON writes the Extensions of the problem alongside its members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	byt, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return byt, err
+	}
+
+	var members = make(map[string]any, len(p.Extensions))
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	if err := json.Unmarshal(byt, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// NewProblem creates the problem details of an error returned while handling the request.
+func NewProblem(req *http.Request, err error) *Problem {
+	var (
+		status, ok = httpStatus(err)
+		problem    = &Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(status),
+			Status:    status,
+			Instance:  req.URL.Path,
+			RequestID: UseRequestID(req),
+		}
+		httpErr  *HTTPError
+		validErr *validationError
+	)
+	if problem.RequestID == "" {
+		problem.RequestID = newRequestID()
+	}
+
+	if errors.As(err, &validErr) {
+		problem.Detail = validErr.sentinel.Error()
+		problem.InvalidParams = newInvalidParams(validErr.err)
+	} else if errors.As(err, &httpErr) {
+		problem.Detail = httpErr.Error()
+	} else if !ok && UseMode(req.Context()) == ModeDevelopment {
+		problem.Detail = err.Error()
+	}
+
+	return problem
+}
+
+// newInvalidParams returns the InvalidParams of a validation error, with one
+// entry per message if the error has ValidationErrors.
+func newInvalidParams(err error) []InvalidParam {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return []InvalidParam{{Reason: err.Error()}}
+	}
+
+	var params = make([]InvalidParam, 0, len(errs))
+	for _, path := range errs.Paths() {
+		for _, msg := range errs[path] {
+			params = append(params, InvalidParam{Name: path, Reason: msg})
+		}
+	}
+	return params
+}
+
+// WriteProblem writes the problem details of the error to the response.
+func WriteProblem(wr http.ResponseWriter, req *http.Request, err error) error {
+	return NewProblem(req, err).Write(wr)
+}
+
+// Write writes the problem to the response with its status code.
+func (p *Problem) Write(wr http.ResponseWriter) error {
+	if p.RequestID != "" {
+		wr.Header().Set(RequestIDHeader, p.RequestID)
+	}
+	wr.Header().Set("Content-Type", "application/problem+json")
+	wr.Header().Set("X-Content-Type-Options", "nosniff")
+	wr.WriteHeader(p.Status)
+	return json.NewEncoder(wr).Encode(p)
+}
+
+// acceptsProblem reports whether the client accepts errors as problem details.
+func acceptsProblem(req *http.Request) bool {
+	return accepts(req, "application/json", "application/problem+json")
+}
+
+// acceptsHTML reports whether the client accepts errors as an HTML page, i.e. a browser.
+func acceptsHTML(req *http.Request) bool {
+	return accepts(req, "text/html")
+}
+
+// accepts reports whether the Accept header of the request lists any of the media types.
+func accepts(req *http.Request, mediaTypes ...string) bool {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		for _, t := range mediaTypes {
+			if strings.TrimSpace(mediaType) == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxRequestIDLength is the maximum length of a request ID sent by the client.
+const maxRequestIDLength = 128
+
+// WithRequestID sets the ID used to correlate the request, i.e. one received from a
+// load balancer using a header other than RequestIDHeader. It must be called before
+// the request is served by a Handler.
+func WithRequestID(req *http.Request, id string) *http.Request {
+	return With(req, requestIDKey, id)
+}
+
+// withRequestID assigns the request its ID, unless it already has one, and sends it in
+// the RequestIDHeader of the response. The ID is taken from the RequestIDHeader sent by
+// the client, if any, or generated.
+func withRequestID(wr http.ResponseWriter, req *http.Request) *http.Request {
+	id, ok := Use[string](req, requestIDKey)
+	if !ok {
+		id = req.Header.Get(RequestIDHeader)
+		if len(id) == 0 || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		req = WithRequestID(req, id)
+	}
+
+	if len(wr.Header().Get(RequestIDHeader)) == 0 {
+		wr.Header().Set(RequestIDHeader, id)
+	}
+	return req
+}
+
+// UseRequestID returns the ID assigned to the request when it was served by a Handler,
+// or set by WithRequestID. Requests not served by a Handler return the value of the
+// RequestIDHeader sent by the client.
+func UseRequestID(req *http.Request) string {
+	if id, ok := Use[string](req, requestIDKey); ok {
+		return id
+	}
+	return req.Header.Get(RequestIDHeader)
+}
+
+func newRequestID() string {
+	var b = make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,157 @@
+package torque_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockSignupForm struct {
+	Email string `json:"email"`
+}
+
+func (f MockSignupForm) Validate(ctx context.Context) error {
+	if !strings.Contains(f.Email, "@") {
+		return errors.New("email is invalid")
+	}
+	return nil
+}
+
+func decodeProblem(wr *httptest.ResponseRecorder) map[string]any {
+	Expect(wr.Header().Get("Content-Type")).To(Equal("application/problem+json"))
+
+	var res map[string]any
+	Expect(json.Unmarshal(wr.Body.Bytes(), &res)).To(Succeed())
+	return res
+}
+
+func TestProblem_Validation(t *testing.T) {
+	h := torque.MustNew[any](&MockAction{
+		ActionFunc: func(wr http.ResponseWriter, req *http.Request) error {
+			_, err := torque.DecodeAndValidateForm[MockSignupForm](req)
+			return err
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(url.Values{"email": {"invalid"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/problem+json")
+	h.ServeHTTP(wr, req)
+	Expect(wr.Code).To(Equal(http.StatusBadRequest))
+
+	res := decodeProblem(wr)
+	Expect(res["title"]).To(Equal("Bad Request"))
+	Expect(res["detail"]).To(Equal(torque.ErrFormValidationFailure.Error()))
+	Expect(res["instance"]).To(Equal("/signup"))
+	Expect(res["requestId"]).NotTo(BeEmpty())
+	Expect(res["invalid-params"]).To(Equal([]any{
+		map[string]any{"reason": "email is invalid"},
+	}))
+	Expect(wr.Header().Get(torque.RequestIDHeader)).To(Equal(res["requestId"]))
+}
+
+func TestProblem_UncaughtError(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+			return MockTemplateProvider{}, errors.New("database password is hunter2")
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	h.ServeHTTP(wr, torque.WithRequestID(req, "abc123"))
+	Expect(wr.Code).To(Equal(http.StatusInternalServerError))
+
+	res := decodeProblem(wr)
+	Expect(res).To(Equal(map[string]any{
+		"type":      "about:blank",
+		"title":     "Internal Server Error",
+		"status":    float64(http.StatusInternalServerError),
+		"instance":  "/",
+		"requestId": "abc123",
+	}))
+}
+
+func TestProblem_UncaughtError_Development(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+			return MockTemplateProvider{}, errors.New("failed")
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	h.ServeHTTP(wr, req.WithContext(torque.WithMode(req.Context(), torque.ModeDevelopment)))
+	Expect(wr.Code).To(Equal(http.StatusInternalServerError))
+
+	res := decodeProblem(wr)
+	Expect(res["detail"]).To(Equal("failed"))
+	Expect(res["stackTrace"]).To(ContainSubstring("goroutine"))
+}
+
+func TestProblem_RequestIDLogged(t *testing.T) {
+	var buf bytes.Buffer
+	h := torque.MustNew[MockTemplateProvider](
+		&MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{}, errors.New("failed")
+			},
+		},
+		torque.WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+	)
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	h.ServeHTTP(wr, req)
+
+	res := decodeProblem(wr)
+	Expect(res["requestId"]).NotTo(BeEmpty())
+	Expect(wr.Header().Get(torque.RequestIDHeader)).To(Equal(res["requestId"]))
+
+	records := decodeLogRecords(&buf)
+	Expect(records).NotTo(BeEmpty())
+	for _, record := range records {
+		Expect(record["request_id"]).To(Equal(res["requestId"]), record["msg"])
+	}
+}
+
+func TestProblem_UncaughtError_HTML(t *testing.T) {
+	h := torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+		LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+			return MockTemplateProvider{}, errors.New("database password is hunter2")
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9")
+	req.Header.Set(torque.RequestIDHeader, "abc123")
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusInternalServerError))
+	Expect(wr.Header().Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+	Expect(wr.Header().Get(torque.RequestIDHeader)).To(Equal("abc123"))
+	Expect(wr.Body.String()).To(ContainSubstring("500 Internal Server Error"))
+	Expect(wr.Body.String()).To(ContainSubstring("Request ID: abc123"))
+	Expect(wr.Body.String()).NotTo(ContainSubstring("hunter2"))
+}
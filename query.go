@@ -10,30 +10,49 @@ var (
 	ErrQueryDecodeFailure = errors.New("failed to decode url query parameters")
 )
 
+// DecodeQuery decodes the URL query parameters of the request into a new T,
+// applying the ValidateTag rules of its fields.
 func DecodeQuery[T any](req *http.Request) (*T, error) {
-	d, ok := UseDecoder(req)
-	if !ok {
-		return nil, ErrDecoderUndefined
+	res, err := decodeQuery[T](req)
+	if err != nil {
+		return nil, err
 	}
 
-	var res T
-	err := d.Decode(&res, req.URL.Query())
-	if err != nil {
-		return nil, ErrQueryDecodeFailure
+	if err := validateDecoded(req.Context(), res, nil, ErrQueryValidationFailure); err != nil {
+		return nil, err
 	}
 
-	return &res, nil
+	return res, nil
 }
 
+// DecodeAndValidateQuery decodes the URL query parameters of the request like
+// DecodeQuery and validates them using the Validate method of T.
 func DecodeAndValidateQuery[T SelfValidator](req *http.Request) (*T, error) {
-	res, err := DecodeQuery[T](req)
+	res, err := decodeQuery[T](req)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := (*res).Validate(req.Context()); err != nil {
-		return nil, errors.Wrap(ErrQueryValidationFailure, err.Error())
+	if err := validateDecoded(req.Context(), res, *res, ErrQueryValidationFailure); err != nil {
+		return nil, err
 	}
 
 	return res, nil
 }
+
+func decodeQuery[T any](req *http.Request) (*T, error) {
+	d, ok := UseDecoder(req)
+	if !ok {
+		return nil, ErrDecoderUndefined
+	}
+
+	var res T
+	err := d.Decode(&res, req.URL.Query())
+	if errs, ok := fromSchemaError(err); ok {
+		return nil, &validationError{ErrQueryDecodeFailure, errs}
+	} else if err != nil {
+		return nil, ErrQueryDecodeFailure
+	}
+
+	return &res, nil
+}
@@ -2,77 +2,335 @@ package torque
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 const (
 	parameterKey = "{}"
+	wildcardKey  = "*"
 )
 
 type Router interface {
 	http.Handler
 
-	Handle(pattern string, handler http.Handler)
+	Handle(pattern string, handler http.Handler, opts ...RouteOption)
+	HandleMethod(method, pattern string, handler http.Handler, opts ...RouteOption)
+	Get(pattern string, handler http.Handler, opts ...RouteOption)
+	Post(pattern string, handler http.Handler, opts ...RouteOption)
+	Put(pattern string, handler http.Handler, opts ...RouteOption)
+	Patch(pattern string, handler http.Handler, opts ...RouteOption)
+	Delete(pattern string, handler http.Handler, opts ...RouteOption)
 	HandleFileSystem(pattern string, fs fs.FS)
 	Redirect(pattern string, location string, status int)
 	Match(method, pattern string) (http.Handler, PathParams, bool)
+	SetPathPolicy(policy PathPolicy)
+	Use(middlewares ...Middleware)
+	Host(pattern string, handler http.Handler)
 }
 
+// PathPolicy controls how the Router treats request paths that are not in
+// their canonical form, such as /a//b/ or /a/./b, whose canonical form is /a/b.
+//
+// Nested routers are merged into their parent, so the policy of the outermost
+// router applies to the entire route tree.
+type PathPolicy int
+
+const (
+	// PathPolicyLenient matches non-canonical paths as if they were canonical.
+	// Empty segments and trailing slashes are ignored. This is the default.
+	PathPolicyLenient PathPolicy = iota
+	// PathPolicyRedirect redirects non-canonical paths to their canonical form
+	// if it matches a route. GET and HEAD requests are redirected with a 301,
+	// all other methods with a 308 so the method and body are preserved.
+	PathPolicyRedirect
+	// PathPolicyStrict responds with a 404 to any non-canonical path.
+	PathPolicyStrict
+)
+
+// Middleware wraps an http.Handler with additional behavior. Middlewares
+// added to a Router wrap every handler in the router's subtree.
 type Middleware func(http.Handler) http.Handler
 
+// middlewareStack is the list of middlewares registered to a single router.
+// Trie nodes reference the stack rather than copying it, so middlewares added
+// with Router.Use after a route was registered still apply to that route.
+type middlewareStack struct {
+	middlewares []Middleware
+}
+
+// applyMiddleware wraps the handler with the given stacks. The first stack
+// and the first middleware of each stack are the outermost.
+func applyMiddleware(h http.Handler, stacks []*middlewareStack) http.Handler {
+	for i := len(stacks) - 1; i >= 0; i-- {
+		for j := len(stacks[i].middlewares) - 1; j >= 0; j-- {
+			h = stacks[i].middlewares[j](h)
+		}
+	}
+	return h
+}
+
+var (
+	ErrRouteConflict = errors.New("route conflict")
+)
+
 type trieNode struct {
-	segment   string
-	parent    *trieNode
-	children  map[string]*trieNode
-	handlers  map[string]http.Handler
-	isParam   bool
+	segment  string
+	parent   *trieNode
+	children map[string]*trieNode
+	handlers map[string]http.Handler
+	stacks   map[string][]*middlewareStack
+	isParam  bool
+
+	// routerStacks are the middleware of the routers the node belongs to. They wrap
+	// the responses that aren't served by a route, such as 404s, 405s and redirects.
+	routerStacks []*middlewareStack
+
+	// handlers of the nearest NotFoundProvider and MethodNotAllowedProvider
+	notFound         Handler
+	methodNotAllowed Handler
+
 	paramName string
+	matcher   ParamMatcher
 }
 
 type router struct {
 	h      Handler
 	root   *trieNode
 	prefix string
+
+	pathPolicy    PathPolicy
+	pathPolicySet bool
+	stack         *middlewareStack
+	hosts         []*hostRoute
+
+	// names maps route names to their full route pattern
+	names map[string]string
+
+	// errs collects any problems found while registering routes. They are
+	// reported all at once when the router is created.
+	errs []error
 }
 
-func createRouter[T ViewModel](h *handlerImpl[T], routeFunc func(r Router)) *router {
+func createRouter[T ViewModel](h *handlerImpl[T], routeFunc func(r Router)) (*router, error) {
 	r := &router{
 		h:      h,
 		prefix: h.path,
-		root: &trieNode{
-			children: make(map[string]*trieNode),
-			handlers: map[string]http.Handler{"*": h},
-		},
+		names:  make(map[string]string),
+		stack:  &middlewareStack{middlewares: h.middlewares},
+	}
+	r.root = &trieNode{
+		children: make(map[string]*trieNode),
+		handlers: map[string]http.Handler{wildcardKey: h},
+		stacks:   map[string][]*middlewareStack{wildcardKey: {r.stack}},
+
+		routerStacks: []*middlewareStack{r.stack},
+
+		notFound:         h.notFound,
+		methodNotAllowed: h.methodNotAllowed,
 	}
 
 	routeFunc(r)
 
-	return r
+	if len(r.errs) != 0 {
+		return nil, errors.Join(r.errs...)
+	}
+
+	return r, nil
 }
 
 func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	h, params, ok := r.Match(req.Method, req.URL.Path)
+	// handlers that aren't mounted to the router, such as NotFound handlers,
+	// inherit the router's options through the request context
+	var opts = r.h.requestOptions(req)
+	ctx := context.WithValue(req.Context(), optionsContextKey, opts)
+	req = req.WithContext(ctx)
+
+	// every request handled by the router is recorded, including the
+	// responses of redirects, NotFound and MethodNotAllowed handlers
+	w, req, observe := withRequestMetrics(w, req, opts.metrics, "")
+	defer observe()
+
+	host, hostParams := r.matchHost(req)
+
+	if r.pathPolicy != PathPolicyLenient && !r.matchesCatchAll(host, req.URL.Path) {
+		if canonical := canonicalPath(req.URL.Path); canonical != req.URL.Path {
+			var ok bool
+			if host != nil {
+				ok = host.matchesPath(req.Method, canonical)
+			} else {
+				_, _, ok = r.Match(req.Method, canonical)
+			}
+
+			if ok && r.pathPolicy == PathPolicyRedirect {
+				applyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					redirectCanonical(w, req, canonical)
+				}), r.nearestStacks(host, canonical)).ServeHTTP(w, req)
+			} else {
+				r.notFound(w, req, host)
+			}
+			return
+		}
+	}
+
+	if host != nil {
+		r.serveHost(w, req, host, hostParams)
+		return
+	}
+
+	h, params, pattern, ok := r.matchRoute(req.Method, req.URL.Path)
 	if !ok {
-		http.NotFound(w, req)
+		r.notFound(w, req, nil)
 		return
 	}
+	setMetricsPattern(req, pattern)
 
-	ctx := req.Context()
+	// keep parameters captured from the host, if any
+	if hostParams, ok := req.Context().Value(paramsContextKey).(PathParams); ok {
+		for key, value := range hostParams {
+			if _, exists := params[key]; !exists {
+				params[key] = value
+			}
+		}
+	}
+
+	ctx = req.Context()
 	ctx = context.WithValue(ctx, paramsContextKey, params)
+	ctx = context.WithValue(ctx, routerContextKey, r)
+	ctx = context.WithValue(ctx, patternContextKey, pattern)
 
 	h.ServeHTTP(w, req.WithContext(ctx))
 }
 
-func (r *router) Handle(path string, h http.Handler) {
-	r.handleMethod("*", path, h)
+// notFound serves the NotFound handler of the nearest NotFoundProvider along
+// the request path, or a plain 404 if there is none. The response is wrapped
+// by the middleware of the routers serving the path, or the host route.
+func (r *router) notFound(w http.ResponseWriter, req *http.Request, host *hostRoute) {
+	var handler http.Handler = http.HandlerFunc(http.NotFound)
+	h := r.root.nearest(strings.Split(req.URL.Path, "/"), func(n *trieNode) Handler {
+		return n.notFound
+	})
+	if h != nil {
+		handler = withStatus(h, http.StatusNotFound)
+	}
+
+	ctx := context.WithValue(req.Context(), routerContextKey, r)
+	applyMiddleware(handler, r.nearestStacks(host, req.URL.Path)).ServeHTTP(w, req.WithContext(ctx))
+}
+
+// nearestStacks returns the middleware of the routers serving the path, which are
+// the stacks of the deepest node along the path, or the stacks of the host route.
+func (r *router) nearestStacks(host *hostRoute, path string) []*middlewareStack {
+	if host != nil {
+		return append([]*middlewareStack{r.stack}, host.stacks...)
+	}
+
+	var stacks []*middlewareStack
+	r.root.walk(strings.Split(path, "/"), func(n *trieNode) {
+		if n.routerStacks != nil {
+			stacks = n.routerStacks
+		}
+	})
+	return stacks
+}
+
+// SetPathPolicy sets the path policy of the router. Only the outermost router
+// handles non-canonical paths, so routers that are mounted to another router,
+// or registered as a host, fail to be created if they set a policy.
+func (r *router) SetPathPolicy(policy PathPolicy) {
+	r.pathPolicy = policy
+	r.pathPolicySet = true
+}
+
+// Use appends middlewares to the router. They wrap every handler in the
+// router's subtree, including handlers of nested routers and the router's
+// own 404, 405 and redirect responses, in the order they were added.
+func (r *router) Use(middlewares ...Middleware) {
+	r.stack.middlewares = append(r.stack.middlewares, middlewares...)
+}
+
+// matchesCatchAll reports whether the path is served by a catch-all route, such as a file
+// system. The path policy isn't applied to them, because the rest of the path is passed
+// to their handler as is, i.e. http.FileServer redirects directories to a trailing slash.
+func (r *router) matchesCatchAll(host *hostRoute, path string) bool {
+	var root = r.root
+	if host != nil {
+		if router := host.handler.getRouter(); router != nil {
+			root = router.root
+		} else {
+			return false
+		}
+	}
+	node := root.match(strings.Split(path, "/"), make(PathParams))
+	return node != nil && node.parent != nil && node.parent.children[wildcardKey] == node
+}
+
+// canonicalPath returns the cleaned form of the given request path, without
+// repeated or trailing slashes and with dot segments resolved.
+func canonicalPath(p string) string {
+	if len(p) == 0 {
+		return "/"
+	}
+	return path.Clean("/" + p)
+}
+
+func redirectCanonical(w http.ResponseWriter, req *http.Request, canonical string) {
+	var status = http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+
+	var u = *req.URL
+	u.Path = canonical
+	u.RawPath = ""
+	http.Redirect(w, req, u.RequestURI(), status)
+}
+
+func (r *router) Handle(path string, h http.Handler, opts ...RouteOption) {
+	r.handleMethod(wildcardKey, path, h, opts...)
+}
+
+// HandleMethod registers the handler for the given HTTP method only. Requests
+// to the same path using a different method are answered with a 405. Handlers
+// registered for GET also serve HEAD requests, unless a HEAD handler is registered.
+func (r *router) HandleMethod(method, path string, h http.Handler, opts ...RouteOption) {
+	r.handleMethod(strings.ToUpper(method), path, h, opts...)
+}
+
+func (r *router) Get(path string, h http.Handler, opts ...RouteOption) {
+	r.handleMethod(http.MethodGet, path, h, opts...)
+}
+
+func (r *router) Post(path string, h http.Handler, opts ...RouteOption) {
+	r.handleMethod(http.MethodPost, path, h, opts...)
+}
+
+func (r *router) Put(path string, h http.Handler, opts ...RouteOption) {
+	r.handleMethod(http.MethodPut, path, h, opts...)
+}
+
+func (r *router) Patch(path string, h http.Handler, opts ...RouteOption) {
+	r.handleMethod(http.MethodPatch, path, h, opts...)
+}
+
+func (r *router) Delete(path string, h http.Handler, opts ...RouteOption) {
+	r.handleMethod(http.MethodDelete, path, h, opts...)
 }
 
 // handleMethod registers a handler or merges a router if passed.
-func (r *router) handleMethod(method, path string, h http.Handler) {
+func (r *router) handleMethod(method, path string, h http.Handler, opts ...RouteOption) {
+	var o = routeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var handler http.Handler
 	switch h.(type) {
 	case Handler:
@@ -90,132 +348,482 @@ func (r *router) handleMethod(method, path string, h http.Handler) {
 		segments = strings.Split(fullPath, "/")
 		node     = r.root
 	)
-	for _, segment := range segments {
+	for i, segment := range segments {
 		if segment == "" {
 			continue
 		}
 
-		isParam := strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
-		var key string
-		if isParam {
-			key = parameterKey
-		} else {
-			key = segment
+		key, paramName, constraint, isParam := parseSegment(segment)
+		if key == wildcardKey && i != len(segments)-1 {
+			r.errs = append(r.errs, fmt.Errorf("catch-all segment %q must be the last segment of route %q", segment, fullPath))
+			return
 		}
 
-		if _, exists := node.children[key]; !exists {
+		if child, exists := node.children[key]; exists && child.paramName != paramName {
+			r.errs = append(r.errs, fmt.Errorf("%w: segment %q of route %q conflicts with existing segment %q", ErrRouteConflict, segment, fullPath, child.segment))
+			return
+		} else if !exists {
+			matcher, err := compileParamMatcher(constraint)
+			if err != nil {
+				r.errs = append(r.errs, fmt.Errorf("invalid constraint in segment %q of route %q: %w", segment, fullPath, err))
+				return
+			}
 			node.children[key] = &trieNode{
-				segment:  segment,
-				parent:   node,
-				children: make(map[string]*trieNode),
-				handlers: make(map[string]http.Handler),
-				isParam:  isParam,
-				paramName: func() string {
-					if isParam {
-						return segment[1 : len(segment)-1] // Extract param name (e.g., userId from {userId})
-					}
-					return ""
-				}(),
+				segment:   segment,
+				parent:    node,
+				children:  make(map[string]*trieNode),
+				handlers:  make(map[string]http.Handler),
+				stacks:    make(map[string][]*middlewareStack),
+				isParam:   isParam,
+				paramName: paramName,
+				matcher:   matcher,
+
+				routerStacks: []*middlewareStack{r.stack},
 			}
 		}
 
 		node = node.children[key]
 	}
 
-	// Store the handler at the final node for the given method (e.g., GET)
+	// Store the handler at the final node for the given method (e.g., GET).
+	// The router's own handler is only a placeholder for the index route.
+	if existing, exists := node.handlers[method]; exists && existing != r.h {
+		r.errs = append(r.errs, fmt.Errorf("%w: %s %q is already registered", ErrRouteConflict, method, fullPath))
+		return
+	}
 	node.handlers[method] = handler
+	node.stacks[method] = []*middlewareStack{r.stack}
+	if handler, ok := handler.(Handler); ok && handler.getRouter() == nil && len(handler.getMiddlewares()) != 0 {
+		// handlers with a router apply their middleware via the router's stack
+		node.stacks[method] = append(node.stacks[method], &middlewareStack{middlewares: handler.getMiddlewares()})
+	}
+
+	if len(o.name) != 0 {
+		r.addName(o.name, fullPath)
+	}
 
 	if handler, ok := handler.(Handler); ok {
+		// handlers inherit the logger of the handler they are mounted to
+		handler.setMount(r.h)
+
 		// create a relationship between the parent and child
 		if r.h.HasOutlet() {
 			// This child route could have a parent if it provides a layout.
 			// Layouts can be many layers so find the greatest parent
-			var parentMostHandler = handler
-			for {
-				if parentMostHandler.GetParent() != nil {
-					parentMostHandler = parentMostHandler.GetParent()
-				} else {
-					break
+			rootHandler(handler).setParent(r.h)
+
+			// if the child could not provide a layout for its own NotFound or
+			// MethodNotAllowed handlers, they are rendered in this outlet
+			for _, h := range []Handler{handler.getNotFound(), handler.getMethodNotAllowed()} {
+				if h != nil && nearestOutlet(h.GetParent()) == nil {
+					rootHandler(h).setParent(r.h)
 				}
 			}
-			parentMostHandler.setParent(r.h)
+		}
+
+		// the child's providers take precedence from the node it is mounted at
+		if nf := handler.getNotFound(); nf != nil {
+			node.notFound = nf
+		}
+		if mna := handler.getMethodNotAllowed(); mna != nil {
+			node.methodNotAllowed = mna
 		}
 
 		// "merge-up" the radix sub-trie from the child router. when this handler's internal
 		// router is ever executed it will need to know about its children during Router.Match.
 		if handler.getRouter() != nil {
+			if handler.getRouter().pathPolicySet {
+				r.errs = append(r.errs, fmt.Errorf("path policy of the router mounted at %q has no effect, it must be set by the outermost router", fullPath))
+			}
+
 			var childRouter = handler.getRouter().root
-			for key, child := range childRouter.children {
-				node.children[key] = child
+			for _, child := range childRouter.children {
+				child.prependStack(r.stack)
 			}
-			if len(childRouter.handlers) > 0 {
-				node.handlers[method] = childRouter.handlers[method]
+			node.routerStacks = append([]*middlewareStack{r.stack}, childRouter.routerStacks...)
+			if err := mergeChildren(node, childRouter, fullPath); err != nil {
+				r.errs = append(r.errs, err)
+				return
+			}
+			if h, ok := childRouter.handlers[method]; ok {
+				node.handlers[method] = h
+				node.stacks[method] = append([]*middlewareStack{r.stack}, childRouter.stacks[method]...)
+			} else {
+				// the handler serves the index route itself, wrapped by its router's middleware
+				node.stacks[method] = append(node.stacks[method], handler.getRouter().stack)
+			}
+			for name, pattern := range handler.getRouter().names {
+				r.addName(name, filepath.Join(fullPath, pattern))
+			}
+			for _, host := range handler.getRouter().hosts {
+				if fullPath != "/" {
+					r.errs = append(r.errs, fmt.Errorf("host %q must be registered by a router mounted at the root path, not %q", host.pattern, fullPath))
+					continue
+				}
+				host.stacks = append([]*middlewareStack{handler.getRouter().stack}, host.stacks...)
+				r.addHost(host)
+			}
+		}
+	}
+}
+
+// rootHandler returns the greatest parent of the given Handler.
+func rootHandler(h Handler) Handler {
+	for h.GetParent() != nil {
+		h = h.GetParent()
+	}
+	return h
+}
+
+// nearestOutlet returns the first Handler in the parent chain, starting
+// with the given Handler, that renders an outlet.
+func nearestOutlet(h Handler) Handler {
+	for ; h != nil; h = h.GetParent() {
+		if h.HasOutlet() {
+			return h
+		}
+	}
+	return nil
+}
+
+// mergeChildren merges the children of src into dst. Nodes present in both
+// tries are merged recursively so that neither side's routes are shadowed.
+func mergeChildren(dst, src *trieNode, path string) error {
+	for key, child := range src.children {
+		existing, exists := dst.children[key]
+		if !exists {
+			child.parent = dst
+			dst.children[key] = child
+			continue
+		}
+
+		var childPath = filepath.Join(path, child.segment)
+		if existing.paramName != child.paramName {
+			return fmt.Errorf("%w: segment %q of route %q conflicts with existing segment %q", ErrRouteConflict, child.segment, childPath, existing.segment)
+		}
+		for method, h := range child.handlers {
+			if _, exists := existing.handlers[method]; exists {
+				return fmt.Errorf("%w: %s %q is already registered", ErrRouteConflict, method, childPath)
 			}
+			existing.handlers[method] = h
+			existing.stacks[method] = child.stacks[method]
+		}
+		if child.notFound != nil {
+			existing.notFound = child.notFound
+		}
+		if child.methodNotAllowed != nil {
+			existing.methodNotAllowed = child.methodNotAllowed
+		}
+		if err := mergeChildren(existing, child, childPath); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// prependStack adds the given middleware stack as the outermost stack of
+// every handler in the subtree.
+func (n *trieNode) prependStack(stack *middlewareStack) {
+	for method := range n.handlers {
+		n.stacks[method] = append([]*middlewareStack{stack}, n.stacks[method]...)
+	}
+	n.routerStacks = append([]*middlewareStack{stack}, n.routerStacks...)
+	for _, child := range n.children {
+		child.prependStack(stack)
+	}
 }
 
 // Match finds a handler based on the method and path
 func (r *router) Match(method, path string) (http.Handler, PathParams, bool) {
+	h, params, _, ok := r.matchRoute(method, path)
+	return h, params, ok
+}
+
+// matchRoute is the same as Match, but also returns the pattern of the matched route.
+func (r *router) matchRoute(method, path string) (http.Handler, PathParams, string, bool) {
 	params := make(map[string]string)
-	segments := strings.Split(path, "/")
 
 	// Traverse the radix trie to find the matching handler
-	node := r.root
+	node := r.root.match(strings.Split(path, "/"), params)
+	if node == nil {
+		return nil, nil, "", false
+	}
+
+	// Return the handler if it exists for the given method or wildcard.
+	// Routes registered for GET also serve HEAD requests.
+	var handler http.Handler
+	if h, ok := node.handlers[method]; ok {
+		handler = applyMiddleware(h, node.stacks[method])
+	} else if h, ok := node.handlers[http.MethodGet]; ok && method == http.MethodHead {
+		handler = applyMiddleware(h, node.stacks[http.MethodGet])
+	} else if h, ok := node.handlers[wildcardKey]; ok {
+		handler = applyMiddleware(h, node.stacks[wildcardKey])
+	}
+
+	if handler != nil {
+		return handler, params, node.pattern(), true
+	} else {
+		// the path matched a route, but not for this method
+		custom := r.root.nearest(strings.Split(path, "/"), func(n *trieNode) Handler {
+			return n.methodNotAllowed
+		})
+		return applyMiddleware(methodNotAllowedHandler(node.allowedMethods(), custom), node.routerStacks), params, node.pattern(), true
+	}
+}
+
+// pattern returns the route pattern of the node, i.e. /users/{id:int}
+func (n *trieNode) pattern() string {
+	var segments = make([]string, 0)
+	for node := n; node.parent != nil; node = node.parent {
+		segments = append([]string{node.segment}, segments...)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// nearest follows the path down the trie and returns the deepest non-nil
+// Handler returned by fn.
+func (n *trieNode) nearest(segments []string, fn func(*trieNode) Handler) Handler {
+	var res Handler
+	n.walk(segments, func(node *trieNode) {
+		if h := fn(node); h != nil {
+			res = h
+		}
+	})
+	return res
+}
+
+// walk follows the path down the trie and calls fn with every node along it,
+// starting with n. Like match, static children are preferred over parameters
+// and catch-alls, but there is no backtracking.
+func (n *trieNode) walk(segments []string, fn func(*trieNode)) {
+	var node = n
+	fn(node)
 	for _, segment := range segments {
 		if segment == "" {
 			continue
 		}
 
-		if child, exists := node.children[segment]; exists {
-			node = child
-		} else if paramChild, exists := node.children["{}"]; exists {
-			node = paramChild
-			params[node.paramName] = segment
-		} else if wildcardChild, exists := node.children["*"]; exists {
-			node = wildcardChild
-			break
+		var next *trieNode
+		if child, exists := node.children[segment]; exists && !child.isParam && segment != wildcardKey {
+			next = child
 		} else {
-			return nil, nil, false
+			for _, child := range node.paramChildren() {
+				if child.matcher == nil || child.matcher(segment) {
+					next = child
+					break
+				}
+			}
 		}
+		if next == nil {
+			next = node.children[wildcardKey]
+		}
+		if next == nil {
+			break
+		}
+
+		node = next
+		fn(node)
 	}
+}
 
-	// Return the handler if it exists for the given method or wildcard.
-	var handler http.Handler
-	if h, ok := node.handlers[method]; ok {
-		handler = h
-	} else if h, ok := node.handlers["*"]; ok {
-		handler = h
+// match walks the trie depth-first looking for a node with handlers that
+// matches the given path segments. Static children are tried first, then
+// parameters (constrained before unconstrained) and finally catch-alls. If a
+// branch fails to match, the next candidate is tried.
+func (n *trieNode) match(segments []string, params map[string]string) *trieNode {
+	for len(segments) != 0 && segments[0] == "" {
+		segments = segments[1:]
 	}
 
-	if handler != nil {
-		return handler, params, true
-	} else {
-		return nil, nil, false
+	if len(segments) == 0 {
+		if len(n.handlers) != 0 {
+			return n
+		}
+		// A named catch-all also matches an empty remainder, i.e. /files/{path...}
+		// matches /files/ with an empty path parameter.
+		if child, exists := n.children[wildcardKey]; exists && child.paramName != "" && len(child.handlers) != 0 {
+			params[child.paramName] = ""
+			return child
+		}
+		return nil
+	}
+
+	var segment = segments[0]
+	if child, exists := n.children[segment]; exists && !child.isParam && segment != wildcardKey {
+		if node := child.match(segments[1:], params); node != nil {
+			return node
+		}
+	}
+
+	for _, child := range n.paramChildren() {
+		if child.matcher != nil && !child.matcher(segment) {
+			continue
+		}
+		if node := child.match(segments[1:], params); node != nil {
+			params[child.paramName] = segment
+			return node
+		}
 	}
+
+	if child, exists := n.children[wildcardKey]; exists && len(child.handlers) != 0 {
+		if child.paramName != "" {
+			// named catch-all parameters capture the rest of the path
+			params[child.paramName] = strings.Join(segments, "/")
+		}
+		return child
+	}
+
+	return nil
+}
+
+// paramChildren returns the single-segment parameter children of the node.
+// Constrained parameters are ordered before the unconstrained parameter so
+// that they get the first chance to match.
+func (n *trieNode) paramChildren() []*trieNode {
+	var children []*trieNode
+	for key, child := range n.children {
+		if child.isParam && key != wildcardKey {
+			children = append(children, child)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if (children[i].matcher == nil) != (children[j].matcher == nil) {
+			return children[i].matcher != nil
+		}
+		return children[i].segment < children[j].segment
+	})
+	return children
+}
+
+// parseSegment determines the trie key for a single route pattern segment.
+//
+//	static      -> key "static"
+//	{name}      -> key "{}", paramName "name"
+//	{name:int}  -> key "{:int}", paramName "name", constraint "int"
+//	{name...}   -> key "*",  paramName "name"
+//	*           -> key "*"
+func parseSegment(segment string) (key, paramName, constraint string, isParam bool) {
+	if segment == wildcardKey {
+		return wildcardKey, "", "", false
+	} else if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+		return segment, "", "", false
+	}
+
+	// Extract param name (e.g., userId from {userId})
+	paramName = segment[1 : len(segment)-1]
+	if strings.HasSuffix(paramName, "...") {
+		return wildcardKey, strings.TrimSuffix(paramName, "..."), "", true
+	}
+	if i := strings.Index(paramName, ":"); i != -1 {
+		constraint = paramName[i+1:]
+		return "{:" + constraint + "}", paramName[:i], constraint, true
+	}
+	return parameterKey, paramName, "", true
+}
+
+// registeredMethods returns the sorted list of methods registered to the node.
+func (n *trieNode) registeredMethods() []string {
+	methods := make([]string, 0, len(n.handlers))
+	for method := range n.handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// allowedMethods returns the sorted list of methods the node serves, including
+// HEAD if the node has a GET handler.
+func (n *trieNode) allowedMethods() []string {
+	methods := n.registeredMethods()
+	if _, ok := n.handlers[http.MethodGet]; ok {
+		if _, ok := n.handlers[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+			sort.Strings(methods)
+		}
+	}
+	return methods
+}
+
+// methodNotAllowedHandler responds with a 405 and lists the allowed
+// methods in the Allow header, as required by RFC 9110. If a custom
+// handler is given it is used to render the response.
+func methodNotAllowedHandler(allowed []string, custom Handler) http.Handler {
+	return NoOutlet(http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		wr.Header().Set("Allow", strings.Join(allowed, ", "))
+		if custom != nil {
+			withStatus(custom, http.StatusMethodNotAllowed).ServeHTTP(wr, req)
+			return
+		}
+		http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
+	}))
+}
+
+// withStatus serves the Handler as if it was a GET request matched by the
+// router, replacing any successful status code with the given status. This
+// allows the Handler to render within its layout's outlet.
+func withStatus(h Handler, status int) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			req = req.Clone(req.Context())
+			req.Method = http.MethodGet
+		}
+		ctx := context.WithValue(req.Context(), routerMatchContextKey, true)
+		h.ServeHTTP(&statusResponseWriter{ResponseWriter: wr, status: status}, req.WithContext(ctx))
+	})
+}
+
+// statusResponseWriter replaces a 200 status code with the given status.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if code == http.StatusOK {
+		code = w.status
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
 func (r *router) HandleFileSystem(pattern string, fs fs.FS) {
 	pattern = strings.TrimSuffix(pattern, "/*")
 
 	if r.h.GetMode() == ModeDevelopment {
-		logFileSystem(fs)
+		logFileSystem(r.h.requestOptions(nil).getLogger(), pattern, fs)
 	}
 
-	r.handleMethod("GET", pattern+"/*", NoOutlet(http.StripPrefix(pattern, http.FileServer(http.FS(fs)))))
+	r.handleMethod(http.MethodGet, pattern+"/*", NoOutlet(http.StripPrefix(pattern, http.FileServer(http.FS(fs)))))
 }
 
-func logFileSystem(fsys fs.FS) {
+func logFileSystem(logger *slog.Logger, pattern string, fsys fs.FS) {
 	var walkFn func(path string, d fs.DirEntry, err error) error
 
 	walkFn = func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
-		} else if d.IsDir() {
-			log.Printf("Dir: %s", path)
-		} else {
-			log.Printf("File: %s", path)
 		}
+		logger.Debug("serving file system entry",
+			slog.String("stage", "router"),
+			slog.String("pattern", pattern),
+			slog.String("path", path),
+			slog.Bool("dir", d.IsDir()),
+		)
 		return nil
 	}
 
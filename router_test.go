@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"testing/fstest"
 
 	. "github.com/onsi/gomega"
 
@@ -643,3 +644,763 @@ func TestRouter_HandleFileSystem(t *testing.T) {
 	Expect(res.StatusCode).To(Equal(http.StatusOK))
 	Expect(string(byt)).To(Equal("console.log('hello world!');"))
 }
+
+func TestRouter_HandleMethod(t *testing.T) {
+	h := torque.MustNew[any](&struct {
+		MockRouterProvider
+	}{
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.Get("/users", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+					_, err := wr.Write([]byte("list users"))
+					Expect(err).NotTo(HaveOccurred())
+				}))
+				r.Post("/users", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+					_, err := wr.Write([]byte("create user"))
+					Expect(err).NotTo(HaveOccurred())
+				}))
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for method, expected := range map[string]string{
+		http.MethodGet:  "list users",
+		http.MethodPost: "create user",
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/users", nil)
+		h.ServeHTTP(wr, req)
+
+		res := wr.Result()
+		byt, err := io.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Body.Close()).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(string(byt)).To(Equal(expected))
+	}
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	h := torque.MustNew[any](&struct {
+		MockRouterProvider
+	}{
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.Get("/users", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}))
+				r.Post("/users", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}))
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/users", nil)
+	h.ServeHTTP(wr, req)
+
+	res := wr.Result()
+	defer Expect(res.Body.Close()).To(BeNil())
+	Expect(res.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	Expect(res.Header.Get("Allow")).To(Equal("GET, HEAD, POST"))
+}
+
+func TestRouter_Head(t *testing.T) {
+	fs, err := fs.Sub(testFilesystem, "testdata/router_test")
+	if err != nil {
+		panic(err)
+	}
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Get("/users", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				wr.Header().Set("X-Method", req.Method)
+			}))
+			r.HandleMethod(http.MethodHead, "/status", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				wr.Header().Set("X-Method", "explicit")
+			}))
+			r.Get("/status", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}))
+			r.Get("/page", torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+				LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+					return MockTemplateProvider{Message: "page"}, nil
+				},
+			}))
+			r.HandleFileSystem("/s", fs)
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		method string
+	}{
+		{"/users", http.StatusOK, http.MethodHead},
+		{"/status", http.StatusOK, "explicit"},
+		{"/s/file.js", http.StatusOK, ""},
+		{"/page", http.StatusOK, ""},
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodHead, tc.path, nil)
+		h.ServeHTTP(wr, req)
+
+		Expect(wr.Code).To(Equal(tc.status), tc.path)
+		Expect(wr.Header().Get("X-Method")).To(Equal(tc.method), tc.path)
+	}
+
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodPost, "/users", nil))
+	Expect(wr.Code).To(Equal(http.StatusMethodNotAllowed))
+	Expect(wr.Header().Get("Allow")).To(Equal("GET, HEAD"))
+}
+
+func TestRouter_CatchAllPathParam(t *testing.T) {
+	h := torque.MustNew[any](&struct {
+		MockRouterProvider
+	}{
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.Handle("/files/{path...}", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+					_, err := wr.Write([]byte(torque.GetPathParam(req, "path")))
+					Expect(err).NotTo(HaveOccurred())
+				}))
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for path, expected := range map[string]string{
+		"/files/a.txt":           "a.txt",
+		"/files/docs/guide/a.md": "docs/guide/a.md",
+		"/files/":                "",
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		h.ServeHTTP(wr, req)
+
+		res := wr.Result()
+		byt, err := io.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Body.Close()).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(string(byt)).To(Equal(expected))
+	}
+}
+
+func TestRouter_Conflicts(t *testing.T) {
+	var noop = http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {})
+
+	RegisterTestingT(t)
+
+	for name, routerFunc := range map[string]func(r torque.Router){
+		"duplicate route": func(r torque.Router) {
+			r.Handle("/users", noop)
+			r.Handle("/users", noop)
+		},
+		"duplicate method": func(r torque.Router) {
+			r.Get("/users", noop)
+			r.Get("/users", noop)
+		},
+		"conflicting param names": func(r torque.Router) {
+			r.Handle("/users/{id}", noop)
+			r.Handle("/users/{userId}/posts", noop)
+		},
+		"shadowed child route": func(r torque.Router) {
+			r.Handle("/users/list", noop)
+			r.Handle("/users", torque.MustNew[any](&MockRouterProvider{
+				RouterFunc: func(r torque.Router) {
+					r.Handle("/list", noop)
+				},
+			}))
+		},
+	} {
+		_, err := torque.New[any](&MockRouterProvider{RouterFunc: routerFunc})
+		Expect(err).To(MatchError(torque.ErrRouteConflict), name)
+	}
+}
+
+func TestRouter_MergedChildRoutes(t *testing.T) {
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/users/list", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				_, err := wr.Write([]byte("list"))
+				Expect(err).NotTo(HaveOccurred())
+			}))
+			r.Handle("/users", torque.MustNew[any](&MockRouterProvider{
+				RouterFunc: func(r torque.Router) {
+					r.Handle("/new", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+						_, err := wr.Write([]byte("new"))
+						Expect(err).NotTo(HaveOccurred())
+					}))
+				},
+			}))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for path, expected := range map[string]string{
+		"/users/list": "list",
+		"/users/new":  "new",
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		h.ServeHTTP(wr, req)
+
+		res := wr.Result()
+		byt, err := io.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Body.Close()).To(BeNil())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(string(byt)).To(Equal(expected))
+	}
+}
+
+func TestRouter_Routes(t *testing.T) {
+	var noop = http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {})
+
+	h := torque.MustNew[MockDivOutletTemplateProvider](&struct {
+		MockLoader[MockDivOutletTemplateProvider]
+		MockRouterProvider
+	}{
+		MockLoader: MockLoader[MockDivOutletTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockDivOutletTemplateProvider, error) {
+				return MockDivOutletTemplateProvider{}, nil
+			},
+		},
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.Get("/users/{id:int}", noop, torque.RouteName("user"))
+				r.Post("/users/{id:int}", noop)
+				r.Handle("/_routes", torque.RoutesHandler())
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+
+	routes := h.Routes()
+	Expect(routes).To(HaveLen(4))
+
+	Expect(routes[0].Pattern).To(Equal("/"))
+	Expect(routes[0].Methods).To(Equal([]string{"*"}))
+	Expect(routes[0].Interfaces).To(Equal([]string{"Loader", "TemplateProvider", "RouterProvider"}))
+
+	Expect(routes[1].Pattern).To(Equal("/_routes"))
+
+	Expect(routes[2].Pattern).To(Equal("/users/{id:int}"))
+	Expect(routes[2].Name).To(Equal("user"))
+	Expect(routes[2].Methods).To(Equal([]string{"GET"}))
+	Expect(routes[2].Controller).To(Equal("http.HandlerFunc"))
+	Expect(routes[2].Layouts).To(HaveLen(1))
+	Expect(routes[3].Methods).To(Equal([]string{"POST"}))
+
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/_routes", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Body.String()).To(ContainSubstring("/users/{id:int}"))
+}
+
+func TestRouter_PathPolicy(t *testing.T) {
+	var createHandler = func(policy torque.PathPolicy) torque.Handler {
+		return torque.MustNew[any](&MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.SetPathPolicy(policy)
+				r.Handle("/a/b", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+					_, err := wr.Write([]byte("ok"))
+					Expect(err).NotTo(HaveOccurred())
+				}))
+			},
+		})
+	}
+
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		policy   torque.PathPolicy
+		method   string
+		path     string
+		status   int
+		location string
+	}{
+		{torque.PathPolicyLenient, http.MethodGet, "/a//b/", http.StatusOK, ""},
+		{torque.PathPolicyRedirect, http.MethodGet, "/a/b", http.StatusOK, ""},
+		{torque.PathPolicyRedirect, http.MethodGet, "/a//b/?q=1", http.StatusMovedPermanently, "/a/b?q=1"},
+		{torque.PathPolicyRedirect, http.MethodPost, "/a/./b/", http.StatusPermanentRedirect, "/a/b"},
+		{torque.PathPolicyRedirect, http.MethodGet, "/a/c/", http.StatusNotFound, ""},
+		{torque.PathPolicyStrict, http.MethodGet, "/a/b", http.StatusOK, ""},
+		{torque.PathPolicyStrict, http.MethodGet, "/a/b/", http.StatusNotFound, ""},
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		createHandler(tc.policy).ServeHTTP(wr, req)
+
+		Expect(wr.Code).To(Equal(tc.status), tc.path)
+		Expect(wr.Header().Get("Location")).To(Equal(tc.location), tc.path)
+	}
+}
+
+func TestRouter_PathPolicy_FileSystem(t *testing.T) {
+	var fsys = fstest.MapFS{
+		"dir/index.html": {Data: []byte("index")},
+	}
+
+	RegisterTestingT(t)
+
+	for _, policy := range []torque.PathPolicy{torque.PathPolicyLenient, torque.PathPolicyRedirect, torque.PathPolicyStrict} {
+		h := torque.MustNew[any](&MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.SetPathPolicy(policy)
+				r.HandleFileSystem("/static", fsys)
+			},
+		})
+
+		// directories are served with a trailing slash by http.FileServer
+		wr := httptest.NewRecorder()
+		h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/static/dir/", nil))
+		Expect(wr.Code).To(Equal(http.StatusOK), "policy %d", policy)
+		Expect(wr.Body.String()).To(Equal("index"), "policy %d", policy)
+
+		wr = httptest.NewRecorder()
+		h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/static/dir", nil))
+		Expect(wr.Code).To(Equal(http.StatusMovedPermanently), "policy %d", policy)
+		Expect(wr.Header().Get("Location")).To(Equal("dir/"), "policy %d", policy)
+	}
+}
+
+func TestRouter_PathPolicy_Nested(t *testing.T) {
+	var child = func() torque.Handler {
+		return torque.MustNew[any](&MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.SetPathPolicy(torque.PathPolicyStrict)
+				r.Handle("/b", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}))
+			},
+		})
+	}
+
+	RegisterTestingT(t)
+
+	_, err := torque.New[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/a", child())
+		},
+	})
+	Expect(err).To(MatchError(ContainSubstring(`path policy of the router mounted at "/a" has no effect`)))
+
+	_, err = torque.New[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Host("example.com", child())
+		},
+	})
+	Expect(err).To(MatchError(ContainSubstring(`path policy of the router of host "example.com" has no effect`)))
+}
+
+type MockMiddlewareProvider struct {
+	MiddlewaresFunc func() []torque.Middleware
+}
+
+func (m MockMiddlewareProvider) Middlewares() []torque.Middleware {
+	return m.MiddlewaresFunc()
+}
+
+func TestRouter_Middleware(t *testing.T) {
+	var trace = func(name string) torque.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				wr.Header().Add("X-Trace", name)
+				next.ServeHTTP(wr, req)
+			})
+		}
+	}
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/child", torque.MustNew[any](&struct {
+				MockMiddlewareProvider
+				MockRouterProvider
+			}{
+				MockMiddlewareProvider: MockMiddlewareProvider{
+					MiddlewaresFunc: func() []torque.Middleware {
+						return []torque.Middleware{trace("child")}
+					},
+				},
+				MockRouterProvider: MockRouterProvider{
+					RouterFunc: func(r torque.Router) {
+						r.Use(trace("child-router"))
+						r.Handle("/leaf", torque.MustNewV(&struct {
+							MockVanillaHandler
+							MockMiddlewareProvider
+						}{
+							MockVanillaHandler: MockVanillaHandler{
+								HandleFunc: func(wr http.ResponseWriter, req *http.Request) {},
+							},
+							MockMiddlewareProvider: MockMiddlewareProvider{
+								MiddlewaresFunc: func() []torque.Middleware {
+									return []torque.Middleware{trace("leaf")}
+								},
+							},
+						}))
+					},
+				},
+			}))
+			// middleware applies to routes registered before the call to Use
+			r.Use(trace("root"))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/child/leaf", nil)
+	h.ServeHTTP(wr, req)
+
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(wr.Header().Values("X-Trace")).To(Equal([]string{"root", "child", "child-router", "leaf"}))
+}
+
+func TestRouter_Middleware_Unmatched(t *testing.T) {
+	var trace = func(name string) torque.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				wr.Header().Add("X-Trace", name)
+				next.ServeHTTP(wr, req)
+			})
+		}
+	}
+
+	h := torque.MustNew[any](&struct {
+		MockRouterProvider
+		MockMiddlewareProvider
+	}{
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.SetPathPolicy(torque.PathPolicyRedirect)
+				r.Use(trace("root"))
+				r.Get("/a", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}))
+				r.Handle("/child", torque.MustNew[any](&MockRouterProvider{
+					RouterFunc: func(r torque.Router) {
+						r.Use(trace("child"))
+						r.Get("/b", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}))
+					},
+				}))
+			},
+		},
+		MockMiddlewareProvider: MockMiddlewareProvider{
+			MiddlewaresFunc: func() []torque.Middleware {
+				return []torque.Middleware{trace("provider")}
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+		trace  []string
+	}{
+		{http.MethodGet, "/a", http.StatusOK, []string{"provider", "root"}},
+		{http.MethodPost, "/a", http.StatusMethodNotAllowed, []string{"provider", "root"}},
+		{http.MethodGet, "/missing", http.StatusNotFound, []string{"provider", "root"}},
+		{http.MethodGet, "/a/", http.StatusMovedPermanently, []string{"provider", "root"}},
+		{http.MethodPost, "/child/b", http.StatusMethodNotAllowed, []string{"provider", "root", "child"}},
+		{http.MethodGet, "/child/missing", http.StatusNotFound, []string{"provider", "root", "child"}},
+	} {
+		wr := httptest.NewRecorder()
+		h.ServeHTTP(wr, httptest.NewRequest(tc.method, tc.path, nil))
+
+		Expect(wr.Code).To(Equal(tc.status), tc.path)
+		Expect(wr.Header().Values("X-Trace")).To(Equal(tc.trace), tc.path)
+	}
+}
+
+func TestRouter_Middleware_MountedIndex(t *testing.T) {
+	var trace = func(name string) torque.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				wr.Header().Add("X-Trace", name)
+				next.ServeHTTP(wr, req)
+			})
+		}
+	}
+
+	var child = func() torque.Handler {
+		return torque.MustNew[MockTemplateProvider](&struct {
+			MockLoader[MockTemplateProvider]
+			MockMiddlewareProvider
+			MockRouterProvider
+		}{
+			MockLoader: MockLoader[MockTemplateProvider]{
+				LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+					return MockTemplateProvider{Message: "index"}, nil
+				},
+			},
+			MockMiddlewareProvider: MockMiddlewareProvider{
+				MiddlewaresFunc: func() []torque.Middleware {
+					return []torque.Middleware{trace("child")}
+				},
+			},
+			MockRouterProvider: MockRouterProvider{
+				RouterFunc: func(r torque.Router) {
+					r.Use(trace("child-router"))
+					r.Get("/leaf", torque.MustNewV(&MockVanillaHandler{
+						HandleFunc: func(wr http.ResponseWriter, req *http.Request) {},
+					}))
+				},
+			},
+		})
+	}
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Get("/get", child())
+			r.Post("/post", child())
+			r.Use(trace("root"))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/get"},
+		{http.MethodPost, "/post"},
+	} {
+		wr := httptest.NewRecorder()
+		h.ServeHTTP(wr, httptest.NewRequest(tc.method, tc.path, nil))
+
+		Expect(wr.Header().Values("X-Trace")).To(Equal([]string{"root", "child", "child-router"}), tc.path)
+	}
+}
+
+type MockNotFoundProvider struct {
+	NotFoundFunc func() torque.Handler
+}
+
+func (m MockNotFoundProvider) NotFound() torque.Handler {
+	return m.NotFoundFunc()
+}
+
+type MockMethodNotAllowedProvider struct {
+	MethodNotAllowedFunc func() torque.Handler
+}
+
+func (m MockMethodNotAllowedProvider) MethodNotAllowed() torque.Handler {
+	return m.MethodNotAllowedFunc()
+}
+
+func TestRouter_NotFoundProvider(t *testing.T) {
+	var message = func(msg string) torque.Handler {
+		return torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{Message: msg}, nil
+			},
+		})
+	}
+
+	h := torque.MustNew[MockDivOutletTemplateProvider](&struct {
+		MockLoader[MockDivOutletTemplateProvider]
+		MockRouterProvider
+		MockNotFoundProvider
+		MockMethodNotAllowedProvider
+	}{
+		MockLoader: MockLoader[MockDivOutletTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockDivOutletTemplateProvider, error) {
+				return MockDivOutletTemplateProvider{}, nil
+			},
+		},
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.Get("/users", message("users"))
+				r.Handle("/admin", torque.MustNew[any](&struct {
+					MockRouterProvider
+					MockNotFoundProvider
+				}{
+					MockRouterProvider: MockRouterProvider{
+						RouterFunc: func(r torque.Router) {
+							r.Handle("/settings", message("settings"))
+						},
+					},
+					MockNotFoundProvider: MockNotFoundProvider{
+						NotFoundFunc: func() torque.Handler { return message("admin not found") },
+					},
+				}))
+			},
+		},
+		MockNotFoundProvider: MockNotFoundProvider{
+			NotFoundFunc: func() torque.Handler { return message("not found") },
+		},
+		MockMethodNotAllowedProvider: MockMethodNotAllowedProvider{
+			MethodNotAllowedFunc: func() torque.Handler { return message("method not allowed") },
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/unknown", http.StatusNotFound, "<div>not found</div>"},
+		{http.MethodPost, "/unknown", http.StatusNotFound, "<div>not found</div>"},
+		{http.MethodGet, "/admin/unknown", http.StatusNotFound, "<div>admin not found</div>"},
+		{http.MethodPost, "/users", http.StatusMethodNotAllowed, "<div>method not allowed</div>"},
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		h.ServeHTTP(wr, req)
+
+		Expect(wr.Code).To(Equal(tc.status), tc.path)
+		Expect(wr.Body.String()).To(Equal(tc.body), tc.path)
+	}
+}
+
+func TestRouter_NotFoundProvider_Nil(t *testing.T) {
+	h, err := torque.New[MockDivOutletTemplateProvider](&struct {
+		MockLoader[MockDivOutletTemplateProvider]
+		MockRouterProvider
+		MockNotFoundProvider
+		MockMethodNotAllowedProvider
+	}{
+		MockLoader: MockLoader[MockDivOutletTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockDivOutletTemplateProvider, error) {
+				return MockDivOutletTemplateProvider{}, nil
+			},
+		},
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.Get("/users", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}))
+			},
+		},
+		MockNotFoundProvider: MockNotFoundProvider{
+			NotFoundFunc: func() torque.Handler { return nil },
+		},
+		MockMethodNotAllowedProvider: MockMethodNotAllowedProvider{
+			MethodNotAllowedFunc: func() torque.Handler { return nil },
+		},
+	})
+
+	RegisterTestingT(t)
+	Expect(err).ToNot(HaveOccurred())
+
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	Expect(wr.Code).To(Equal(http.StatusNotFound))
+
+	wr = httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodPost, "/users", nil))
+	Expect(wr.Code).To(Equal(http.StatusMethodNotAllowed))
+	Expect(wr.Header().Get("Allow")).To(Equal("GET, HEAD"))
+}
+
+func TestRouter_Host(t *testing.T) {
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				_, err := wr.Write([]byte("www"))
+				Expect(err).NotTo(HaveOccurred())
+			}))
+			r.Host("admin.example.com", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				_, err := wr.Write([]byte("admin"))
+				Expect(err).NotTo(HaveOccurred())
+			}))
+			r.Host("{tenant}.example.com", torque.MustNew[any](&MockRouterProvider{
+				RouterFunc: func(r torque.Router) {
+					r.Handle("/projects/{id}", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+						_, err := wr.Write([]byte(torque.GetPathParam(req, "tenant") + ":" + torque.GetPathParam(req, "id")))
+						Expect(err).NotTo(HaveOccurred())
+					}))
+				},
+			}))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		host   string
+		path   string
+		status int
+		body   string
+	}{
+		{"example.com", "/", http.StatusOK, "www"},
+		{"admin.example.com", "/", http.StatusOK, "admin"},
+		{"acme.example.com:8080", "/projects/42", http.StatusOK, "acme:42"},
+		{"acme.example.com", "/unknown", http.StatusNotFound, "404 page not found\n"},
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = tc.host
+		h.ServeHTTP(wr, req)
+
+		Expect(wr.Code).To(Equal(tc.status), tc.host)
+		Expect(wr.Body.String()).To(Equal(tc.body), tc.host)
+	}
+}
+
+func TestRouter_Host_Middleware(t *testing.T) {
+	var count = 0
+	h := torque.MustNew[any](&struct {
+		MockRouterProvider
+		MockMiddlewareProvider
+	}{
+		MockRouterProvider: MockRouterProvider{
+			RouterFunc: func(r torque.Router) {
+				r.SetPathPolicy(torque.PathPolicyStrict)
+				r.Use(func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+						count++
+						next.ServeHTTP(wr, req)
+					})
+				})
+				r.Host("admin.example.com", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+					_, err := wr.Write([]byte("admin"))
+					Expect(err).NotTo(HaveOccurred())
+				}))
+			},
+		},
+		MockMiddlewareProvider: MockMiddlewareProvider{
+			MiddlewaresFunc: func() []torque.Middleware {
+				return []torque.Middleware{func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+						wr.Header().Set("X-Provider", "root")
+						next.ServeHTTP(wr, req)
+					})
+				}}
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for i, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/", http.StatusOK},
+		{"/dashboard", http.StatusOK},
+		{"/dashboard/", http.StatusNotFound},
+	} {
+		wr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = "admin.example.com"
+		h.ServeHTTP(wr, req)
+
+		Expect(wr.Code).To(Equal(tc.status), tc.path)
+		Expect(count).To(Equal(i+1), tc.path)
+		Expect(wr.Header().Get("X-Provider")).To(Equal("root"), tc.path)
+		if tc.status == http.StatusOK {
+			Expect(wr.Body.String()).To(Equal("admin"), tc.path)
+		}
+	}
+}
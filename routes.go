@@ -0,0 +1,283 @@
+package torque
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tylermmorton/tmpl"
+)
+
+// RouteInfo describes a single route registered in a Handler's route tree.
+type RouteInfo struct {
+	// Host is the host pattern the route is registered for, if any.
+	Host string `json:"host,omitempty"`
+	// Pattern is the full route pattern, i.e. /users/{id:int}
+	Pattern string `json:"pattern"`
+	// Name is the name given to the route using RouteName, if any.
+	Name string `json:"name,omitempty"`
+	// Methods are the HTTP methods the route is registered for. A route
+	// registered with Router.Handle matches any method, indicated by "*".
+	Methods []string `json:"methods"`
+	// Controller is the type of the Controller (or http.Handler) serving the route.
+	Controller string `json:"controller"`
+	// Interfaces are the Controller API interfaces implemented by the Controller.
+	Interfaces []string `json:"interfaces,omitempty"`
+	// Layouts is the chain of Controllers the route is rendered within,
+	// starting with the nearest parent.
+	Layouts []string `json:"layouts,omitempty"`
+	// Guards are the names of the Guard functions protecting the route.
+	Guards []string `json:"guards,omitempty"`
+}
+
+// Routes walks the Handler's route tree and returns a description of every
+// registered route, sorted by pattern.
+func (h *handlerImpl[T]) Routes() []RouteInfo {
+	if h.router == nil {
+		info := h.describe()
+		info.Pattern = h.path
+		info.Methods = []string{wildcardKey}
+		return []RouteInfo{info}
+	}
+	return h.router.routes()
+}
+
+// describe returns the RouteInfo of the Handler without any of the
+// information only known to the router, such as the pattern.
+func (h *handlerImpl[T]) describe() RouteInfo {
+	var info = RouteInfo{
+		Controller: h.controllerName(),
+		Interfaces: h.getInterfaces(),
+	}
+
+	for parent := h.GetParent(); parent != nil; parent = parent.GetParent() {
+		info.Layouts = append(info.Layouts, fmt.Sprintf("%T", parent.getController()))
+	}
+
+	for _, guard := range h.guards {
+		info.Guards = append(info.Guards, runtime.FuncForPC(reflect.ValueOf(guard).Pointer()).Name())
+	}
+
+	return info
+}
+
+// getInterfaces returns the names of the Controller API interfaces
+// implemented by the Handler's Controller.
+func (h *handlerImpl[T]) getInterfaces() []string {
+	var (
+		res = make([]string, 0)
+		ctl = h.ctl
+	)
+	if ctl == nil {
+		return res
+	}
+
+	if _, ok := ctl.(Action); ok {
+		res = append(res, "Action")
+	}
+	if _, ok := ctl.(Loader[T]); ok {
+		res = append(res, "Loader")
+	}
+	if _, ok := ctl.(Renderer[T]); ok {
+		res = append(res, "Renderer")
+	} else if _, ok := ctl.(DynamicRenderer); ok {
+		res = append(res, "DynamicRenderer")
+	} else if _, ok := any(new(T)).(tmpl.TemplateProvider); ok {
+		res = append(res, "TemplateProvider")
+	}
+	if _, ok := ctl.(HeaderRenderer[T]); ok {
+		res = append(res, "HeaderRenderer")
+	}
+	if _, ok := ctl.(EventSource); ok {
+		res = append(res, "EventSource")
+	}
+	if _, ok := ctl.(SubscribeHook); ok {
+		res = append(res, "SubscribeHook")
+	}
+	if _, ok := ctl.(UnsubscribeHook); ok {
+		res = append(res, "UnsubscribeHook")
+	}
+	if _, ok := ctl.(SubscriberLimit); ok {
+		res = append(res, "SubscriberLimit")
+	}
+	if _, ok := ctl.(ErrorBoundary); ok {
+		res = append(res, "ErrorBoundary")
+	}
+	if _, ok := ctl.(PanicBoundary); ok {
+		res = append(res, "PanicBoundary")
+	}
+	if _, ok := ctl.(DeadlineProvider); ok {
+		res = append(res, "DeadlineProvider")
+	}
+	if _, ok := ctl.(DeadlineBoundary); ok {
+		res = append(res, "DeadlineBoundary")
+	}
+	if _, ok := ctl.(CancelBoundary); ok {
+		res = append(res, "CancelBoundary")
+	}
+	if _, ok := ctl.(HookProvider); ok {
+		res = append(res, "HookProvider")
+	}
+	if _, ok := ctl.(LayoutProvider); ok {
+		res = append(res, "LayoutProvider")
+	}
+	if _, ok := ctl.(OutletStreamer); ok {
+		res = append(res, "OutletStreamer")
+	}
+	if _, ok := ctl.(NotFoundProvider); ok {
+		res = append(res, "NotFoundProvider")
+	}
+	if _, ok := ctl.(MethodNotAllowedProvider); ok {
+		res = append(res, "MethodNotAllowedProvider")
+	}
+	if _, ok := ctl.(MiddlewareProvider); ok {
+		res = append(res, "MiddlewareProvider")
+	}
+	if _, ok := ctl.(RouterProvider); ok {
+		res = append(res, "RouterProvider")
+	}
+	if _, ok := ctl.(GuardProvider); ok {
+		res = append(res, "GuardProvider")
+	}
+	if _, ok := ctl.(PluginProvider); ok {
+		res = append(res, "PluginProvider")
+	}
+
+	return res
+}
+
+// routes walks the trie depth-first and collects a RouteInfo for every
+// distinct handler registered at each node.
+func (r *router) routes() []RouteInfo {
+	var (
+		res   = make([]RouteInfo, 0)
+		names = make(map[string]string, len(r.names))
+		walk  func(node *trieNode, pattern string)
+	)
+	for name, pattern := range r.names {
+		names[pattern] = name
+	}
+
+	walk = func(node *trieNode, pattern string) {
+		// group the methods by the Handler they are registered to. vanilla
+		// handlers, such as those wrapped by NoOutlet, are not comparable.
+		var (
+			infos   = make([]RouteInfo, 0)
+			indexes = make(map[Handler]int)
+		)
+		for _, method := range node.registeredMethods() {
+			h, ok := node.handlers[method].(Handler)
+			if i, exists := indexes[h]; ok && exists {
+				infos[i].Methods = append(infos[i].Methods, method)
+				continue
+			}
+
+			var info RouteInfo
+			if ok {
+				info = h.describe()
+				indexes[h] = len(infos)
+			} else {
+				info = RouteInfo{Controller: fmt.Sprintf("%T", node.handlers[method])}
+			}
+			info.Pattern = pattern
+			info.Name = names[pattern]
+			info.Methods = []string{method}
+			infos = append(infos, info)
+		}
+		res = append(res, infos...)
+
+		for _, child := range node.children {
+			walk(child, filepath.Join(pattern, child.segment))
+		}
+	}
+	walk(r.root, "/")
+
+	for _, host := range r.hosts {
+		for _, info := range host.handler.Routes() {
+			info.Host = host.pattern
+			res = append(res, info)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Host != res[j].Host {
+			return res[i].Host < res[j].Host
+		}
+		return res[i].Pattern < res[j].Pattern
+	})
+
+	return res
+}
+
+// PrintRoutes writes the route table of the given Handler to w as a
+// human-readable table.
+func PrintRoutes(w io.Writer, h Handler) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err := fmt.Fprintln(tw, "HOST\tPATTERN\tMETHODS\tNAME\tCONTROLLER\tINTERFACES\tLAYOUTS\tGUARDS")
+	if err != nil {
+		return err
+	}
+	for _, route := range h.Routes() {
+		_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			orDash(route.Host),
+			route.Pattern,
+			strings.Join(route.Methods, ","),
+			orDash(route.Name),
+			route.Controller,
+			orDash(strings.Join(route.Interfaces, ",")),
+			orDash(strings.Join(route.Layouts, " > ")),
+			orDash(strings.Join(route.Guards, ",")),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
+
+// RoutesHandler returns an http.Handler that renders the route table of the
+// router it is registered to. The table is rendered as JSON if requested via
+// the Accept header, otherwise as plain text. It is only served when the
+// Handler is running in development mode.
+//
+//	func (Controller) Router(r torque.Router) {
+//		r.Handle("/_routes", torque.RoutesHandler())
+//	}
+func RoutesHandler() http.Handler {
+	return NoOutlet(http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		r, ok := req.Context().Value(routerContextKey).(*router)
+		if !ok || r.h.GetMode() != ModeDevelopment {
+			http.NotFound(wr, req)
+			return
+		}
+
+		if req.Header.Get("Accept") == "application/json" {
+			wr.Header().Set("Content-Type", "application/json")
+			encoder := json.NewEncoder(wr)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(r.h.Routes()); err != nil {
+				http.Error(wr, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		wr.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := PrintRoutes(wr, r.h); err != nil {
+			http.Error(wr, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+}
@@ -0,0 +1,166 @@
+package torque
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout is the time in-flight requests are given to complete
+// once a Server created with NewServer is shut down.
+const DefaultDrainTimeout = 30 * time.Second
+
+// ShutdownEvent is the name of the final event sent to EventSource subscribers and
+// htmx.SSE streams when the Server shuts down. Use it with the sse-close attribute of
+// htmx's sse extension to close the stream on the client.
+const ShutdownEvent = "shutdown"
+
+// ErrServerShutdown is the cause of the request contexts canceled by Server.Shutdown
+// when requests didn't complete within the drain timeout.
+var ErrServerShutdown = errors.New("server shut down before the request completed")
+
+// Server wraps an http.Server, coordinating the graceful shutdown of torque apps.
+//
+// Once Shutdown is called, the channel returned by UseShutdown is closed for every request
+// served by the Server. EventSource subscribers are sent a final ShutdownEvent and their
+// request context is canceled, so long-lived streams don't block the shutdown. Requests
+// that are still in flight after the DrainTimeout have their context canceled as well.
+//
+// A Server can be created from an existing http.Server, i.e. &torque.Server{Server: srv},
+// as long as it is served using the methods of the Server.
+type Server struct {
+	*http.Server
+
+	// DrainTimeout is the time in-flight requests are given to complete once Shutdown
+	// is called. A DrainTimeout of zero or less waits until the context passed to
+	// Shutdown is done.
+	DrainTimeout time.Duration
+
+	initOnce sync.Once
+	once     sync.Once
+	shutdown chan struct{}
+	cancel   context.CancelCauseFunc
+}
+
+// NewServer creates a Server listening on addr serving the given handler.
+func NewServer(addr string, handler http.Handler) *Server {
+	s := &Server{
+		Server: &http.Server{
+			Addr:    addr,
+			Handler: handler,
+		},
+		DrainTimeout: DefaultDrainTimeout,
+	}
+	s.init()
+
+	return s
+}
+
+// init creates the shutdown channel of the Server and sets the BaseContext of the
+// http.Server, so requests can use it. Any BaseContext that was already set is used
+// as the parent of the request contexts.
+func (s *Server) init() {
+	s.initOnce.Do(func() {
+		if s.Server == nil {
+			s.Server = &http.Server{}
+		}
+
+		var ctx context.Context
+		ctx, s.cancel = context.WithCancelCause(context.Background())
+		s.shutdown = make(chan struct{})
+
+		var base = s.BaseContext
+		s.BaseContext = func(l net.Listener) context.Context {
+			var reqCtx = ctx
+			if base != nil {
+				var cancel context.CancelCauseFunc
+				reqCtx, cancel = context.WithCancelCause(base(l))
+				context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+			}
+			return context.WithValue(reqCtx, shutdownKey, (<-chan struct{})(s.shutdown))
+		}
+	})
+}
+
+// ListenAndServe calls http.Server.ListenAndServe once the Server is initialized.
+func (s *Server) ListenAndServe() error {
+	s.init()
+	return s.Server.ListenAndServe()
+}
+
+// ListenAndServeTLS calls http.Server.ListenAndServeTLS once the Server is initialized.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	s.init()
+	return s.Server.ListenAndServeTLS(certFile, keyFile)
+}
+
+// Serve calls http.Server.Serve once the Server is initialized.
+func (s *Server) Serve(l net.Listener) error {
+	s.init()
+	return s.Server.Serve(l)
+}
+
+// ServeTLS calls http.Server.ServeTLS once the Server is initialized.
+func (s *Server) ServeTLS(l net.Listener, certFile, keyFile string) error {
+	s.init()
+	return s.Server.ServeTLS(l, certFile, keyFile)
+}
+
+// Shutdown signals every request served by the Server to shut down and gracefully shuts
+// down the http.Server. If requests didn't complete within the DrainTimeout, their
+// contexts are canceled with ErrServerShutdown and the http.Server is closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.init()
+	s.once.Do(func() {
+		close(s.shutdown)
+	})
+
+	if s.DrainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.DrainTimeout)
+		defer cancel()
+	}
+
+	err := s.Server.Shutdown(ctx)
+	if err != nil {
+		s.cancel(ErrServerShutdown)
+		_ = s.Server.Close()
+	}
+	return err
+}
+
+// ListenAndServeContext listens on the Server's address until ctx is done, i.e. when
+// created with signal.NotifyContext, and then shuts the Server down gracefully.
+func (s *Server) ListenAndServeContext(ctx context.Context) error {
+	var errCh = make(chan error, 1)
+	go func() {
+		errCh <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// UseShutdown returns a channel that is closed once the Server serving the request
+// is shut down. Long-lived requests, such as event streams, should return once it is
+// closed. Requests not served by a Server return a nil channel, which is never closed.
+func UseShutdown(req *http.Request) <-chan struct{} {
+	if shutdown, ok := Use[<-chan struct{}](req, shutdownKey); ok {
+		return shutdown
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package torque_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+	"github.com/tylermmorton/torque/pkg/htmx"
+)
+
+// serveMockServer serves the Server on a random local port and returns its URL
+func serveMockServer(srv *torque.Server) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	return "http://" + ln.Addr().String()
+}
+
+// readEvents subscribes to the event stream at url and sends every line it reads
+func readEvents(url string) chan string {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	Expect(err).NotTo(HaveOccurred())
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := http.DefaultClient.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+	var lines = make(chan string, 16)
+	go func() {
+		defer close(lines)
+		defer res.Body.Close()
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// drainEvents returns every line read until the stream was closed
+func drainEvents(lines chan string) string {
+	var res = make([]string, 0)
+	for line := range lines {
+		res = append(res, line)
+	}
+	return strings.Join(res, "\n")
+}
+
+func TestServer_ShutdownEventSource(t *testing.T) {
+	var subscribed = make(chan struct{}, 1)
+	h := torque.MustNew[any](&struct{ MockEventSource }{mockStreamingEventSource(subscribed)})
+
+	RegisterTestingT(t)
+	srv := torque.NewServer("", h)
+	lines := readEvents(serveMockServer(srv))
+	<-subscribed
+
+	Expect(srv.Shutdown(context.Background())).To(Succeed())
+	Expect(drainEvents(lines)).To(Equal("event: " + torque.ShutdownEvent + "\ndata: \n"))
+	Expect(h.Subscribers()).To(Equal(0))
+}
+
+// lateResponseWriter records writes made after the handler returned
+type lateResponseWriter struct {
+	*httptest.ResponseRecorder
+	returned atomic.Bool
+	late     atomic.Bool
+}
+
+func (w *lateResponseWriter) Write(b []byte) (int, error) {
+	w.late.Store(w.late.Load() || w.returned.Load())
+	return w.ResponseRecorder.Write(b)
+}
+
+func (w *lateResponseWriter) Flush() {
+	w.late.Store(w.late.Load() || w.returned.Load())
+	w.ResponseRecorder.Flush()
+}
+
+func TestServer_ShutdownEventSource_ReturnOnShutdown(t *testing.T) {
+	RegisterTestingT(t)
+
+	for i := 0; i < 50; i++ {
+		var subscribed = make(chan struct{}, 1)
+		h := torque.MustNew[any](&struct{ MockEventSource }{MockEventSource{
+			SubscribeFunc: func(wr http.ResponseWriter, req *http.Request) error {
+				wr.Header().Set("Content-Type", "text/event-stream")
+				wr.WriteHeader(http.StatusOK)
+				wr.(http.Flusher).Flush()
+
+				subscribed <- struct{}{}
+				<-torque.UseShutdown(req)
+				return nil
+			},
+		}})
+
+		srv := torque.NewServer("", h)
+		wr := &lateResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(srv.BaseContext(nil))
+		req.Header.Set("Accept", "text/event-stream")
+
+		var done = make(chan struct{})
+		go func() {
+			defer close(done)
+			h.ServeHTTP(wr, req)
+			wr.returned.Store(true)
+		}()
+		<-subscribed
+
+		Expect(srv.Shutdown(context.Background())).To(Succeed())
+		<-done
+		time.Sleep(time.Millisecond)
+
+		Expect(wr.late.Load()).To(BeFalse())
+		Expect(wr.Body.String()).To(Equal("event: " + torque.ShutdownEvent + "\ndata: \n\n"))
+	}
+}
+
+func TestServer_ShutdownSSE(t *testing.T) {
+	var subscribed = make(chan struct{}, 1)
+	h := http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		_ = htmx.SSE(wr, req, htmx.EventSourceMap{
+			"message": func(ch chan string) {
+				subscribed <- struct{}{}
+			},
+		})
+	})
+
+	RegisterTestingT(t)
+	srv := torque.NewServer("", h)
+	lines := readEvents(serveMockServer(srv))
+	<-subscribed
+
+	Expect(srv.Shutdown(context.Background())).To(Succeed())
+	Expect(drainEvents(lines)).To(Equal("event: " + torque.ShutdownEvent + "\ndata: \n"))
+}
+
+func TestServer_DrainTimeout(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		cause   = make(chan error, 1)
+	)
+	h := http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		close(started)
+		// ignore the shutdown signal until the request context is canceled
+		<-req.Context().Done()
+		cause <- context.Cause(req.Context())
+	})
+
+	RegisterTestingT(t)
+	srv := torque.NewServer("", h)
+	srv.DrainTimeout = 50 * time.Millisecond
+	url := serveMockServer(srv)
+
+	go func() {
+		_, _ = http.Get(url)
+	}()
+	<-started
+
+	Expect(srv.Shutdown(context.Background())).To(MatchError(context.DeadlineExceeded))
+	Eventually(cause).Should(Receive(MatchError(torque.ErrServerShutdown)))
+}
+
+func TestServer_ZeroValue(t *testing.T) {
+	var subscribed = make(chan struct{}, 1)
+	h := http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		_ = htmx.SSE(wr, req, htmx.EventSourceMap{
+			"message": func(ch chan string) {
+				subscribed <- struct{}{}
+			},
+		})
+	})
+
+	RegisterTestingT(t)
+	Expect((&torque.Server{}).Shutdown(context.Background())).To(Succeed())
+
+	srv := &torque.Server{Server: &http.Server{Handler: h}}
+	lines := readEvents(serveMockServer(srv))
+	<-subscribed
+
+	Expect(srv.Shutdown(context.Background())).To(Succeed())
+	Expect(drainEvents(lines)).To(Equal("event: " + torque.ShutdownEvent + "\ndata: \n"))
+}
@@ -14,7 +14,13 @@ type templateRenderer[T ViewModel] struct {
 }
 
 func (t templateRenderer[T]) Render(wr http.ResponseWriter, req *http.Request, vm T) error {
-	opts := make([]tmpl.RenderOption, 0)
+	opts := []tmpl.RenderOption{
+		tmpl.WithFuncs(tmpl.FuncMap{
+			outletIdent:   outletFunc(req),
+			urlForIdent:   urlForFunc(req),
+			deferredIdent: deferredFunc(req),
+		}),
+	}
 	if target, ok := UseRenderTarget(req); ok {
 		opts = append(opts, tmpl.WithTarget(target))
 	}
@@ -33,6 +39,11 @@ func createTemplateRenderer[T ViewModel](tp tmpl.TemplateProvider) (*templateRen
 	r.template, err = tmpl.Compile(
 		tp,
 		tmpl.UseAnalyzers(outletAnalyzer(r)),
+		// placeholder, the request scoped func is provided during Render
+		tmpl.UseFuncs(tmpl.FuncMap{
+			urlForIdent:   urlForFunc(nil),
+			deferredIdent: deferredFunc(nil),
+		}),
 	)
 	if err != nil {
 		return nil, false, err
@@ -41,7 +52,11 @@ func createTemplateRenderer[T ViewModel](tp tmpl.TemplateProvider) (*templateRen
 	return r, r.hasOutlet, nil
 }
 
-const outletIdent = "outlet"
+const (
+	outletIdent   = "outlet"
+	urlForIdent   = "urlFor"
+	deferredIdent = "deferred"
+)
 
 func outletAnalyzer[T ViewModel](t *templateRenderer[T]) tmpl.Analyzer {
 	return func(h *tmpl.AnalysisHelper) tmpl.AnalyzerFunc {
@@ -52,7 +67,8 @@ func outletAnalyzer[T ViewModel](t *templateRenderer[T]) tmpl.Analyzer {
 					h.AddError(node, "outlet can only be defined once per template")
 				} else if node.Ident == outletIdent {
 					t.hasOutlet = true
-					h.AddFunc(outletIdent, func() string { return "{{ . }}" })
+					// placeholder, the request scoped func is provided during Render
+					h.AddFunc(outletIdent, outletFunc(nil))
 				}
 			}
 		}
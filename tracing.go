@@ -0,0 +1,113 @@
+package torque
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Tracer starts a Span for each stage of the request lifecycle, such as guards, hooks, the
+// Loader and the Renderer. It is deliberately small, so it can be implemented by an adapter
+// around an OpenTelemetry tracer, or by the in-memory tracer of the tracetest package.
+//
+// The context returned by Start is passed along to the stage, so spans started by the
+// Controller, i.e. in its Loader, become children of the stage's span.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span is a single traced stage of the request lifecycle.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error)
+	End()
+}
+
+// NoopTracer is a Tracer that doesn't record anything. It is used by Handlers without a tracer.
+type NoopTracer struct{}
+
+func (NoopTracer) Start(ctx context.Context, _ string, _ ...slog.Attr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// WithTracer sets the tracer of the Handler. Like the logger set by WithLogger, the tracer is
+// inherited by every Handler in its route tree and its layouts.
+func WithTracer(tracer Tracer) Option {
+	return func(opts *options) {
+		opts.tracer = tracer
+	}
+}
+
+// getTracer returns the tracer of the options, or a NoopTracer if none was set.
+func (o options) getTracer() Tracer {
+	if o.tracer != nil {
+		return o.tracer
+	}
+	return NoopTracer{}
+}
+
+// startSpan starts a span for a stage of the request lifecycle with the attributes of the
+// Handler. The returned request carries the context of the span. The duration of the stage
+// is recorded to the Handler's Metrics when the span ends.
+func (h *handlerImpl[T]) startSpan(req *http.Request, name string, attrs ...slog.Attr) (*http.Request, Span) {
+	var pattern = routePattern(req, h.path)
+	ctx, span := h.requestOptions(req).getTracer().Start(req.Context(), name, append([]slog.Attr{
+		slog.String("controller", h.controllerName()),
+		slog.String("pattern", pattern),
+	}, attrs...)...)
+
+	// requests are recorded along with their status by withRequestMetrics
+	if metrics := h.requestOptions(req).metrics; metrics != nil && name != "torque.request" {
+		span = &stageSpan{
+			Span:    span,
+			metrics: metrics,
+			pattern: pattern,
+			stage:   strings.TrimPrefix(name, "torque."),
+			start:   time.Now(),
+		}
+	}
+	return req.WithContext(ctx), span
+}
+
+// stageSpan records the duration of a stage to Metrics when it ends.
+type stageSpan struct {
+	Span
+	metrics *Metrics
+	pattern string
+	stage   string
+	start   time.Time
+}
+
+func (s *stageSpan) End() {
+	s.metrics.observeStage(s.pattern, s.stage, time.Since(s.start))
+	s.Span.End()
+}
+
+// endSpan records the error of the stage, if any, and ends the span.
+func endSpan(span Span, err error) {
+	if err != nil && !errors.Is(err, errNotImplemented) {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// startRequestSpan starts the span covering the entire request handled by the Handler.
+// Layouts rendered as part of an outlet chain get a torque.layout span of their own.
+func (h *handlerImpl[T]) startRequestSpan(req *http.Request) (*http.Request, Span) {
+	if _, ok := req.Context().Value(outletContextKey).(outletMarker); ok {
+		return h.startSpan(req, "torque.layout")
+	}
+	return h.startSpan(req, "torque.request",
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+	)
+}
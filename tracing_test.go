@@ -0,0 +1,121 @@
+package torque_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+	"github.com/tylermmorton/torque/pkg/tracetest"
+)
+
+func spanNames(tracer *tracetest.Tracer) []string {
+	var names = make([]string, 0)
+	for _, span := range tracer.Spans() {
+		names = append(names, span.Name)
+	}
+	return names
+}
+
+func TestTracing_Stages(t *testing.T) {
+	var tracer = tracetest.NewTracer()
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/{id}", torque.MustNew[MockTemplateProvider](&MockLoader[MockTemplateProvider]{
+				LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+					return MockTemplateProvider{Message: "ok"}, nil
+				},
+			}))
+		},
+	}, torque.WithTracer(tracer))
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/123", nil))
+	Expect(wr.Code).To(Equal(http.StatusOK))
+	Expect(spanNames(tracer)).To(Equal([]string{"torque.request", "torque.loader", "torque.render"}))
+
+	request := tracer.Find("torque.request")
+	for _, span := range tracer.Spans() {
+		Expect(span.Ended()).To(BeTrue())
+
+		pattern, ok := span.Attr("pattern")
+		Expect(ok).To(BeTrue())
+		Expect(pattern.String()).To(Equal("/{id}"))
+
+		if span != request {
+			Expect(span.Parent).To(Equal(request))
+		}
+	}
+}
+
+func TestTracing_Error(t *testing.T) {
+	var tracer = tracetest.NewTracer()
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockErrorBoundary
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{}, errors.New("failed")
+			},
+		},
+		MockErrorBoundary: mockFailingErrorBoundary,
+	}, torque.WithTracer(tracer))
+
+	RegisterTestingT(t)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	loader := tracer.Find("torque.loader")
+	Expect(loader).NotTo(BeNil())
+	Expect(loader.Err).To(MatchError("failed"))
+	Expect(tracer.Find("torque.render")).To(BeNil())
+}
+
+func TestTracing_Layouts(t *testing.T) {
+	var tracer = tracetest.NewTracer()
+
+	h := torque.MustNew[MockTemplateProvider](&struct {
+		MockLoader[MockTemplateProvider]
+		MockLayoutProvider
+	}{
+		MockLoader: MockLoader[MockTemplateProvider]{
+			LoadFunc: func(req *http.Request) (MockTemplateProvider, error) {
+				return MockTemplateProvider{Message: "Hello world!"}, nil
+			},
+		},
+		MockLayoutProvider: MockLayoutProvider{
+			LayoutFunc: func() torque.Handler {
+				return createMockMessageLayout("")
+			},
+		},
+	}, torque.WithTracer(tracer))
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	h.ServeHTTP(wr, httptest.NewRequest(http.MethodGet, "/", nil))
+	Expect(wr.Body.String()).To(Equal("<div>Hello world!</div>"))
+
+	request := tracer.Find("torque.request")
+	layout := tracer.Find("torque.layout")
+	Expect(request).NotTo(BeNil())
+	Expect(layout).NotTo(BeNil())
+	Expect(layout.Parent).To(Equal(request))
+
+	controller, _ := layout.Attr("controller")
+	Expect(controller.String()).To(Equal("*torque_test.MockLoader[github.com/tylermmorton/torque_test.MockMessageOutletTemplateProvider]"))
+
+	var loaders = 0
+	for _, span := range tracer.Spans() {
+		Expect(span.Ended()).To(BeTrue())
+		if span.Name == "torque.loader" {
+			loaders++
+		}
+	}
+	Expect(loaders).To(Equal(2))
+}
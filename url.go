@@ -0,0 +1,132 @@
+package torque
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrRouterUndefined = errors.New("failed to retrieve router from context")
+	ErrRouteUndefined  = errors.New("no route registered with name")
+)
+
+// RouteOption configures a route when it is registered with a Router.
+type RouteOption func(opts *routeOptions)
+
+type routeOptions struct {
+	name string
+}
+
+// RouteName assigns a name to the route so that its URL can be generated
+// using URLFor or the urlFor template function. Names must be unique across
+// the entire route tree, including nested routers.
+func RouteName(name string) RouteOption {
+	return func(opts *routeOptions) {
+		opts.name = name
+	}
+}
+
+func (r *router) addName(name, pattern string) {
+	if existing, exists := r.names[name]; exists && existing != pattern {
+		r.errs = append(r.errs, fmt.Errorf("%w: route name %q is used by both %q and %q", ErrRouteConflict, name, existing, pattern))
+		return
+	}
+	r.names[name] = pattern
+}
+
+// URLFor builds the path of the route registered under the given name,
+// substituting the given path parameters.
+func (r *router) URLFor(name string, params PathParams) (string, error) {
+	pattern, ok := r.names[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrRouteUndefined, name)
+	}
+	return buildURL(pattern, params)
+}
+
+// buildURL substitutes path parameters into the given route pattern. Values
+// are escaped and checked against the parameter's constraint, if any.
+func buildURL(pattern string, params PathParams) (string, error) {
+	var (
+		segments = strings.Split(pattern, "/")
+		used     = 0
+	)
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+
+		key, paramName, constraint, isParam := parseSegment(segment)
+		if !isParam {
+			if key == wildcardKey {
+				return "", fmt.Errorf("cannot build url for route %q with unnamed wildcard", pattern)
+			}
+			continue
+		}
+
+		value, ok := params[paramName]
+		if !ok {
+			return "", fmt.Errorf("missing path parameter %q for route %q", paramName, pattern)
+		}
+		used++
+
+		if key == wildcardKey {
+			// catch-all values may span multiple segments
+			parts := strings.Split(value, "/")
+			for j := range parts {
+				parts[j] = url.PathEscape(parts[j])
+			}
+			segments[i] = strings.Join(parts, "/")
+			continue
+		}
+
+		matcher, err := compileParamMatcher(constraint)
+		if err != nil {
+			return "", err
+		} else if matcher != nil && !matcher(value) {
+			return "", fmt.Errorf("path parameter %q value %q does not satisfy constraint %q", paramName, value, constraint)
+		}
+		segments[i] = url.PathEscape(value)
+	}
+
+	if used != len(params) {
+		return "", fmt.Errorf("unknown path parameters given for route %q", pattern)
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// URLFor builds the path of a named route using the router that matched the
+// given request. This can be used to build the url passed to RedirectError:
+//
+//	url, err := torque.URLFor(req, "user", torque.PathParams{"id": "1"})
+func URLFor(req *http.Request, name string, params PathParams) (string, error) {
+	r, ok := req.Context().Value(routerContextKey).(*router)
+	if !ok {
+		return "", ErrRouterUndefined
+	}
+	return r.URLFor(name, params)
+}
+
+// urlForFunc returns the urlFor template function. Parameters are passed as
+// alternating key value pairs:
+//
+//	<a href="{{ urlFor "user" "id" .ID }}">Profile</a>
+func urlForFunc(req *http.Request) func(name string, pairs ...any) (string, error) {
+	return func(name string, pairs ...any) (string, error) {
+		if len(pairs)%2 != 0 {
+			return "", fmt.Errorf("urlFor %q expects key value pairs", name)
+		}
+		var params = make(PathParams, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			params[fmt.Sprint(pairs[i])] = fmt.Sprint(pairs[i+1])
+		}
+		if req == nil {
+			return "", ErrRouterUndefined
+		}
+		return URLFor(req, name, params)
+	}
+}
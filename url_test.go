@@ -0,0 +1,94 @@
+package torque_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockLinkTemplateProvider struct {
+	ID string
+}
+
+func (MockLinkTemplateProvider) TemplateText() string {
+	return `<a href="{{ urlFor "file" "id" .ID "path" "docs/a b.md" }}"></a>`
+}
+
+func TestURL_URLFor(t *testing.T) {
+	var noop = http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {})
+
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/users/{id:int}", noop, torque.RouteName("user"))
+			r.Handle("/projects", torque.MustNew[any](&MockRouterProvider{
+				RouterFunc: func(r torque.Router) {
+					r.Get("/{id}/files/{path...}", noop, torque.RouteName("file"))
+				},
+			}))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	url, err := h.URLFor("user", torque.PathParams{"id": "42"})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(url).To(Equal("/users/42"))
+
+	url, err = h.URLFor("file", torque.PathParams{"id": "torque", "path": "docs/a b.md"})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(url).To(Equal("/projects/torque/files/docs/a%20b.md"))
+
+	_, err = h.URLFor("user", torque.PathParams{"id": "tommy"})
+	Expect(err).To(HaveOccurred())
+
+	_, err = h.URLFor("user", torque.PathParams{})
+	Expect(err).To(HaveOccurred())
+
+	_, err = h.URLFor("unknown", torque.PathParams{})
+	Expect(err).To(MatchError(torque.ErrRouteUndefined))
+}
+
+func TestURL_DuplicateRouteName(t *testing.T) {
+	var noop = http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {})
+
+	_, err := torque.New[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/users", noop, torque.RouteName("users"))
+			r.Handle("/people", noop, torque.RouteName("users"))
+		},
+	})
+
+	RegisterTestingT(t)
+	Expect(err).To(MatchError(torque.ErrRouteConflict))
+}
+
+func TestURL_TemplateFunc(t *testing.T) {
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/projects/{id}/files/{path...}", http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {}), torque.RouteName("file"))
+			r.Handle("/", torque.MustNew[MockLinkTemplateProvider](&MockLoader[MockLinkTemplateProvider]{
+				LoadFunc: func(req *http.Request) (MockLinkTemplateProvider, error) {
+					return MockLinkTemplateProvider{ID: "torque"}, nil
+				},
+			}))
+		},
+	})
+
+	RegisterTestingT(t)
+
+	wr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(wr, req)
+
+	res := wr.Result()
+	defer Expect(res.Body.Close()).To(BeNil())
+	byt, err := io.ReadAll(res.Body)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(res.StatusCode).To(Equal(http.StatusOK))
+	Expect(string(byt)).To(Equal(`<a href="/projects/torque/files/docs/a%20b.md"></a>`))
+}
@@ -0,0 +1,385 @@
+package torque
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidateTag is the struct tag holding the validation rules of a field. Rules are
+// separated by commas, i.e. `torque:"required,min=3,max=32"`. The tag is specific to
+// torque, so it doesn't interfere with the tags of other validation packages:
+//
+//   - required: the field must not be empty, or zero for numbers and booleans.
+//   - min=n, max=n: the minimum and maximum value of a number, or the minimum and
+//     maximum length of a string, slice or map.
+//   - len=n: the exact length of a string, slice or map.
+//   - email: the string must be a valid email address.
+//   - oneof=a b c: the value must be one of the space-separated options.
+//   - pattern=re: the string must match the regular expression. The pattern
+//     must be the last rule, as it includes the rest of the tag.
+//
+// Empty strings, slices, maps and nil pointers are only checked by required, so
+// optional fields can be left empty. The rules are applied by the Decode functions,
+// before the Validate method of a SelfValidator.
+const ValidateTag = "torque"
+
+// validateRule checks a single rule of a field against its value. It returns the
+// validation message if the value is invalid.
+type validateRule func(v reflect.Value) (string, bool)
+
+// validateField is a field of a struct with validate rules, or a struct field that
+// has nested fields to validate.
+type validateField struct {
+	index    []int
+	path     string
+	required bool
+	rules    []validateRule
+}
+
+var validateFieldsCache sync.Map // map[reflect.Type][]validateField
+
+// validateTags applies the ValidateTag rules of the struct v points to.
+func validateTags(v any) (ValidationErrors, error) {
+	var errs = ValidationErrors{}
+	if err := validateValue(errs, "", reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+func validateValue(errs ValidationErrors, path string, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields, err := compileValidateFields(v.Type())
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			var (
+				fv        = v.FieldByIndex(field.index)
+				fieldPath = joinPath(path, field.path)
+			)
+			if field.required && isZeroValue(fv) {
+				errs.Add(fieldPath, "is required")
+				continue
+			}
+			if isEmptyValue(fv) {
+				continue
+			}
+			for _, rule := range field.rules {
+				if msg, ok := rule(indirect(fv)); !ok {
+					errs.Add(fieldPath, msg)
+				}
+			}
+			if err := validateValue(errs, fieldPath, fv); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if !hasNestedFields(v.Type()) {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(errs, joinPath(path, strconv.Itoa(i)), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compileValidateFields compiles the validate rules of the fields of a struct type.
+// Fields are named after their json alias, like the decoder used by the Decode functions.
+func compileValidateFields(typ reflect.Type) ([]validateField, error) {
+	if fields, ok := validateFieldsCache.Load(typ); ok {
+		return fields.([]validateField), nil
+	}
+
+	var fields []validateField
+	for i := 0; i < typ.NumField(); i++ {
+		var sf = typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// embedded structs without an alias are decoded as if their fields were
+		// declared by the outer struct
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			embedded, err := compileValidateFields(sf.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, field := range embedded {
+				field.index = append([]int{i}, field.index...)
+				fields = append(fields, field)
+			}
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		field, err := compileValidateField(sf, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag of field %s.%s: %w", ValidateTag, typ.Name(), sf.Name, err)
+		}
+		if field.required || len(field.rules) != 0 || hasNestedFields(sf.Type) {
+			fields = append(fields, field)
+		}
+	}
+
+	validateFieldsCache.Store(typ, fields)
+	return fields, nil
+}
+
+func compileValidateField(sf reflect.StructField, name string) (validateField, error) {
+	var (
+		field = validateField{index: sf.Index, path: name}
+		typ   = indirectType(sf.Type)
+		tag   = sf.Tag.Get(ValidateTag)
+	)
+	for len(tag) != 0 {
+		var rule string
+		if tag = strings.TrimSpace(tag); strings.HasPrefix(tag, "pattern=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+			continue
+		case "required":
+			field.required = true
+			continue
+		}
+
+		fn, err := compileValidateRule(typ, name, arg)
+		if err != nil {
+			return field, err
+		}
+		field.rules = append(field.rules, fn)
+	}
+	return field, nil
+}
+
+func compileValidateRule(typ reflect.Type, name, arg string) (validateRule, error) {
+	switch name {
+	case "min", "max":
+		return compileBoundRule(typ, name, arg)
+	case "len":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", name)
+		}
+		noun, ok := lengthNoun(typ)
+		if !ok {
+			return nil, fmt.Errorf("%s is not supported by %s fields", name, typ)
+		}
+		return func(v reflect.Value) (string, bool) {
+			return fmt.Sprintf("must be exactly %d %s", n, noun), lengthOf(v) == n
+		}, nil
+	case "email":
+		if typ.Kind() != reflect.String {
+			return nil, fmt.Errorf("%s is not supported by %s fields", name, typ)
+		}
+		return func(v reflect.Value) (string, bool) {
+			addr, err := mail.ParseAddress(v.String())
+			return "must be a valid email address", err == nil && addr.Address == v.String()
+		}, nil
+	case "oneof":
+		var options = strings.Fields(arg)
+		if len(options) == 0 {
+			return nil, fmt.Errorf("%s requires at least one option", name)
+		}
+		return func(v reflect.Value) (string, bool) {
+			var str = fmt.Sprint(v.Interface())
+			for _, option := range options {
+				if str == option {
+					return "", true
+				}
+			}
+			return fmt.Sprintf("must be one of %s", strings.Join(options, ", ")), false
+		}, nil
+	case "pattern":
+		if typ.Kind() != reflect.String {
+			return nil, fmt.Errorf("%s is not supported by %s fields", name, typ)
+		}
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) (string, bool) {
+			return "is not in a valid format", re.MatchString(v.String())
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rule %q", name)
+	}
+}
+
+// compileBoundRule compiles the min and max rules, comparing the value of numbers
+// and the length of strings, slices and maps.
+func compileBoundRule(typ reflect.Type, name, arg string) (validateRule, error) {
+	var (
+		bound, err = strconv.ParseFloat(arg, 64)
+		limit      = map[string]string{"min": "at least", "max": "at most"}[name]
+		inBounds   = func(f float64) bool {
+			if name == "min" {
+				return f >= bound
+			}
+			return f <= bound
+		}
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+
+	if noun, ok := lengthNoun(typ); ok {
+		return func(v reflect.Value) (string, bool) {
+			return fmt.Sprintf("must be %s %s %s", limit, arg, noun), inBounds(float64(lengthOf(v)))
+		}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) (string, bool) {
+			return fmt.Sprintf("must be %s %s", limit, arg), inBounds(float64(v.Int()))
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value) (string, bool) {
+			return fmt.Sprintf("must be %s %s", limit, arg), inBounds(float64(v.Uint()))
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) (string, bool) {
+			return fmt.Sprintf("must be %s %s", limit, arg), inBounds(v.Float())
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s is not supported by %s fields", name, typ)
+	}
+}
+
+// lengthNoun returns the noun used in the validation messages of types with a length.
+func lengthNoun(typ reflect.Type) (string, bool) {
+	switch typ.Kind() {
+	case reflect.String:
+		return "characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items", true
+	default:
+		return "", false
+	}
+}
+
+func lengthOf(v reflect.Value) int {
+	if v.Kind() == reflect.String {
+		return utf8.RuneCountInString(v.String())
+	}
+	return v.Len()
+}
+
+// hasNestedFields reports whether typ is a struct, or a slice of structs, whose fields
+// may have validate rules. Their rules are compiled once a value is validated, so
+// recursive types are supported.
+func hasNestedFields(typ reflect.Type) bool {
+	typ = indirectType(typ)
+	if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = indirectType(typ.Elem())
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+// isZeroValue reports whether the value of a required field is missing.
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// isEmptyValue reports whether the value of a field is empty, skipping its rules.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// validateDecoded validates a value decoded by the Decode functions, applying its
+// ValidateTag rules and the Validate method of the SelfValidator, if any. The
+// ValidationErrors of both are merged into a single validationError.
+func validateDecoded(ctx context.Context, v any, self SelfValidator, sentinel error) error {
+	errs, err := validateTags(v)
+	if err != nil {
+		return err
+	}
+
+	if self != nil {
+		if err := self.Validate(ctx); err != nil {
+			var selfErrs ValidationErrors
+			if !errors.As(err, &selfErrs) {
+				if len(errs) == 0 {
+					return &validationError{sentinel, err}
+				}
+				return &validationError{sentinel, errors.Join(errs, err)}
+			}
+			for path, msgs := range selfErrs {
+				for _, msg := range msgs {
+					errs.Add(path, msg)
+				}
+			}
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return &validationError{sentinel, err}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package torque_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockAddress struct {
+	Street string `json:"street" torque:"required"`
+	Zip    string `json:"zip" torque:"len=5,pattern=^[0-9]+$"`
+}
+
+type MockSignupTagForm struct {
+	Name      string        `json:"name" torque:"required,min=2,max=8"`
+	Email     string        `json:"email" torque:"required,email"`
+	Role      string        `json:"role" torque:"oneof=admin member"`
+	Age       int           `json:"age" torque:"min=18"`
+	Nickname  string        `json:"nickname" torque:"min=3"`
+	Tags      []string      `json:"tags" torque:"max=2"`
+	Address   MockAddress   `json:"address"`
+	Addresses []MockAddress `json:"addresses"`
+}
+
+type MockTagSelfValidator struct {
+	Password string `json:"password" torque:"required,min=8"`
+	Confirm  string `json:"confirm"`
+}
+
+func (f MockTagSelfValidator) Validate(ctx context.Context) error {
+	var errs = torque.ValidationErrors{}
+	if f.Password != f.Confirm {
+		errs.Add("confirm", "must match the password")
+	}
+	return errs.Err()
+}
+
+type MockInvalidTagForm struct {
+	Age int `json:"age" torque:"email"`
+}
+
+// decodeWith serves the request with a handler calling fn, returning its error
+func decodeWith(req *http.Request, fn func(req *http.Request) error) error {
+	var err error
+	h := torque.MustNewV(&MockVanillaHandler{
+		HandleFunc: func(wr http.ResponseWriter, req *http.Request) {
+			err = fn(req)
+		},
+	})
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return err
+}
+
+func TestValidate_Tags(t *testing.T) {
+	RegisterTestingT(t)
+	err := decodeWith(createMockFormRequest(http.MethodPost, url.Values{
+		"email":              {"not an email"},
+		"name":               {"a"},
+		"role":               {"owner"},
+		"age":                {"0"},
+		"tags":               {"a", "b", "c"},
+		"address.zip":        {"1234a"},
+		"addresses.0.street": {"Main St"},
+		"addresses.0.zip":    {"12345"},
+		"addresses.1.zip":    {"12345"},
+		"addresses.1.street": {""},
+	}), func(req *http.Request) error {
+		_, err := torque.DecodeForm[MockSignupTagForm](req)
+		return err
+	})
+	Expect(err).To(MatchError(torque.ErrFormValidationFailure))
+	Expect(torque.StatusCode(err)).To(Equal(http.StatusBadRequest))
+
+	var errs torque.ValidationErrors
+	Expect(errors.As(err, &errs)).To(BeTrue())
+	Expect(errs).To(Equal(torque.ValidationErrors{
+		"name":               {"must be at least 2 characters"},
+		"email":              {"must be a valid email address"},
+		"role":               {"must be one of admin, member"},
+		"age":                {"must be at least 18"},
+		"tags":               {"must be at most 2 items"},
+		"address.street":     {"is required"},
+		"address.zip":        {"is not in a valid format"},
+		"addresses.1.street": {"is required"},
+	}))
+}
+
+func TestValidate_Tags_Valid(t *testing.T) {
+	RegisterTestingT(t)
+	var res *MockSignupTagForm
+	err := decodeWith(httptest.NewRequest(http.MethodGet, "/?name=tyler&email=tyler@example.com&age=30&address.street=Main+St", nil), func(req *http.Request) (err error) {
+		res, err = torque.DecodeQuery[MockSignupTagForm](req)
+		return err
+	})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(res.Name).To(Equal("tyler"))
+	Expect(res.Address.Street).To(Equal("Main St"))
+}
+
+func TestValidate_Tags_SelfValidator(t *testing.T) {
+	RegisterTestingT(t)
+	err := decodeWith(createMockFormRequest(http.MethodPost, url.Values{
+		"password": {"secret"},
+		"confirm":  {"secrets"},
+	}), func(req *http.Request) error {
+		_, err := torque.DecodeAndValidateForm[MockTagSelfValidator](req)
+		return err
+	})
+	Expect(err).To(MatchError(torque.ErrFormValidationFailure))
+
+	var errs torque.ValidationErrors
+	Expect(errors.As(err, &errs)).To(BeTrue())
+	Expect(errs).To(Equal(torque.ValidationErrors{
+		"password": {"must be at least 8 characters"},
+		"confirm":  {"must match the password"},
+	}))
+}
+
+func TestValidate_Tags_PathParams(t *testing.T) {
+	type params struct {
+		Id string `json:"id" torque:"len=3"`
+	}
+
+	var err error
+	h := torque.MustNew[any](&MockRouterProvider{
+		RouterFunc: func(r torque.Router) {
+			r.Handle("/posts/{id}", torque.MustNewV(&MockVanillaHandler{
+				HandleFunc: func(wr http.ResponseWriter, req *http.Request) {
+					_, err = torque.DecodePathParams[params](req)
+				},
+			}))
+		},
+	})
+
+	RegisterTestingT(t)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/1234", nil))
+	Expect(err).To(MatchError(torque.ErrPathParamValidationFailure))
+	Expect(err.Error()).To(HaveSuffix("id: must be exactly 3 characters"))
+}
+
+func TestValidate_Tags_InvalidTag(t *testing.T) {
+	RegisterTestingT(t)
+	err := decodeWith(httptest.NewRequest(http.MethodGet, "/?age=3", nil), func(req *http.Request) error {
+		_, err := torque.DecodeQuery[MockInvalidTagForm](req)
+		return err
+	})
+	Expect(err).To(MatchError(ContainSubstring("invalid torque tag of field MockInvalidTagForm.Age")))
+	Expect(torque.StatusCode(err)).To(Equal(http.StatusInternalServerError))
+}
+
+func TestValidate_Tags_IgnoresValidatorTags(t *testing.T) {
+	type form struct {
+		Age  int    `json:"age" validate:"gte=18"`
+		Name string `json:"name" validate:"required,alphanum" torque:"max=8"`
+	}
+
+	RegisterTestingT(t)
+	var res *form
+	err := decodeWith(httptest.NewRequest(http.MethodGet, "/?age=3", nil), func(req *http.Request) (err error) {
+		res, err = torque.DecodeQuery[form](req)
+		return err
+	})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(res.Age).To(Equal(3))
+}
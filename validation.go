@@ -0,0 +1,118 @@
+package torque
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/schema"
+)
+
+// ValidationErrors maps the path of a field, i.e. "email" or "address.street", to its
+// validation messages. Paths use the same names as the form or query parameters the
+// field is decoded from.
+//
+// A SelfValidator can return ValidationErrors from Validate, and decoding failures and
+// ValidateTag rules of the Decode functions are reported as ValidationErrors. After an Action returns
+// ReloadWithError, UseValidationErrors returns them so templates can show per-field
+// messages.
+type ValidationErrors map[string][]string
+
+// Add adds a validation message for the field at path.
+func (e ValidationErrors) Add(path, msg string) {
+	e[path] = append(e[path], msg)
+}
+
+// Has reports whether the field at path has any validation messages.
+func (e ValidationErrors) Has(path string) bool {
+	return len(e[path]) != 0
+}
+
+// Get returns the validation messages of the field at path.
+func (e ValidationErrors) Get(path string) []string {
+	return e[path]
+}
+
+// First returns the first validation message of the field at path, or an empty string.
+func (e ValidationErrors) First(path string) string {
+	if msgs := e[path]; len(msgs) != 0 {
+		return msgs[0]
+	}
+	return ""
+}
+
+// Paths returns the paths of the fields with validation messages, sorted.
+func (e ValidationErrors) Paths() []string {
+	var paths = make([]string, 0, len(e))
+	for path, msgs := range e {
+		if len(msgs) != 0 {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Err returns the ValidationErrors as an error, or nil if there are none. It
+// is meant to be returned from Validate once every field has been validated.
+func (e ValidationErrors) Err() error {
+	if len(e.Paths()) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e ValidationErrors) Error() string {
+	var res = make([]string, 0, len(e))
+	for _, path := range e.Paths() {
+		res = append(res, fmt.Sprintf("%s: %s", path, strings.Join(e[path], ", ")))
+	}
+	return strings.Join(res, "; ")
+}
+
+// UseValidationErrors returns the ValidationErrors attached to the request context by
+// ReloadWithError, if any. The returned ValidationErrors are never nil, so they can be
+// used in templates without checking.
+func UseValidationErrors(req *http.Request) ValidationErrors {
+	var errs ValidationErrors
+	if err := UseError(req); err != nil && errors.As(err, &errs) {
+		return errs
+	}
+	return ValidationErrors{}
+}
+
+// fromSchemaError translates the errors of a gorilla/schema decoder into ValidationErrors.
+// It returns false if err isn't caused by the decoded values, i.e. if the decoded type
+// isn't supported.
+func fromSchemaError(err error) (ValidationErrors, bool) {
+	var multiErr schema.MultiError
+	if !errors.As(err, &multiErr) {
+		return nil, false
+	}
+
+	var errs = ValidationErrors{}
+	for path, err := range multiErr {
+		var (
+			convErr    schema.ConversionError
+			emptyErr   schema.EmptyFieldError
+			unknownErr schema.UnknownKeyError
+		)
+		switch {
+		case errors.As(err, &convErr):
+			if convErr.Type != nil {
+				errs.Add(path, fmt.Sprintf("must be a valid %s", convErr.Type.Kind()))
+			} else {
+				errs.Add(path, "is invalid")
+			}
+		case errors.As(err, &emptyErr):
+			errs.Add(path, "is required")
+		case errors.As(err, &unknownErr):
+			errs.Add(path, "is not a known field")
+		default:
+			errs.Add(path, err.Error())
+		}
+	}
+	return errs, true
+}
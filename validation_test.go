@@ -0,0 +1,192 @@
+package torque_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/tylermmorton/torque"
+)
+
+type MockProfileForm struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func (f MockProfileForm) Validate(ctx context.Context) error {
+	var errs = torque.ValidationErrors{}
+	if f.Name == "" {
+		errs.Add("name", "is required")
+	}
+	if f.Age < 18 {
+		errs.Add("age", "must be at least 18")
+	}
+	return errs.Err()
+}
+
+func createMockFormRequest(method string, values url.Values) *http.Request {
+	req := httptest.NewRequest(method, "/", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestValidationErrors_ReloadWithError(t *testing.T) {
+	h := torque.MustNew[MockViewModel](&struct {
+		MockAction
+		MockLoader[MockViewModel]
+		MockRenderer[MockViewModel]
+	}{
+		MockAction: MockAction{
+			ActionFunc: func(wr http.ResponseWriter, req *http.Request) error {
+				_, err := torque.DecodeAndValidateForm[MockProfileForm](req)
+				if err != nil {
+					return torque.ReloadWithError(err)
+				}
+				return nil
+			},
+		},
+		MockLoader: MockLoader[MockViewModel]{
+			LoadFunc: func(req *http.Request) (MockViewModel, error) {
+				errs := torque.UseValidationErrors(req)
+				return MockViewModel{Message: errs.Error()}, nil
+			},
+		},
+		MockRenderer: MockRenderer[MockViewModel]{
+			RenderFunc: func(wr http.ResponseWriter, req *http.Request, vm MockViewModel) error {
+				_, err := wr.Write([]byte(vm.Message))
+				return err
+			},
+		},
+	})
+
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		values url.Values
+		body   string
+	}{
+		// errors returned by the Validate method of the form
+		{url.Values{"age": {"16"}}, "age: must be at least 18; name: is required"},
+		// values that can't be converted to the type of their field
+		{url.Values{"name": {"tyler"}, "age": {"old"}}, "age: must be a valid int"},
+	} {
+		wr := httptest.NewRecorder()
+		h.ServeHTTP(wr, createMockFormRequest(http.MethodPost, tc.values))
+		Expect(wr.Code).To(Equal(http.StatusOK))
+		Expect(wr.Body.String()).To(Equal(tc.body))
+	}
+}
+
+func TestValidationErrors_DecodeQuery(t *testing.T) {
+	var err error
+	h := torque.MustNewV(&MockVanillaHandler{
+		HandleFunc: func(wr http.ResponseWriter, req *http.Request) {
+			_, err = torque.DecodeQuery[MockProfileForm](req)
+		},
+	})
+
+	RegisterTestingT(t)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?age=old&color=red", nil))
+	Expect(err).To(MatchError(torque.ErrQueryDecodeFailure))
+	Expect(torque.StatusCode(err)).To(Equal(http.StatusBadRequest))
+
+	var errs torque.ValidationErrors
+	Expect(errors.As(err, &errs)).To(BeTrue())
+	Expect(errs).To(Equal(torque.ValidationErrors{
+		"age":   {"must be a valid int"},
+		"color": {"is not a known field"},
+	}))
+}
+
+func TestValidationErrors_ProblemDetails(t *testing.T) {
+	h := torque.MustNew[any](&MockAction{
+		ActionFunc: func(wr http.ResponseWriter, req *http.Request) error {
+			_, err := torque.DecodeAndValidateForm[MockProfileForm](req)
+			return err
+		},
+	})
+
+	RegisterTestingT(t)
+	wr := httptest.NewRecorder()
+	req := createMockFormRequest(http.MethodPost, url.Values{"age": {"16"}})
+	req.Header.Set("Accept", "application/json")
+	h.ServeHTTP(wr, req)
+	Expect(wr.Code).To(Equal(http.StatusBadRequest))
+	Expect(decodeProblem(wr)["invalid-params"]).To(Equal([]any{
+		map[string]any{"name": "age", "reason": "must be at least 18"},
+		map[string]any{"name": "name", "reason": "is required"},
+	}))
+}
+
+func TestFormState(t *testing.T) {
+	RegisterTestingT(t)
+
+	for name, tc := range map[string]struct {
+		action func(wr http.ResponseWriter, req *http.Request) error
+		req    *http.Request
+		body   string
+	}{
+		"validation errors": {
+			action: func(wr http.ResponseWriter, req *http.Request) error {
+				_, err := torque.DecodeAndValidateForm[MockProfileForm](req)
+				return torque.ReloadWithError(err)
+			},
+			req:  createMockFormRequest(http.MethodPost, url.Values{"name": {"tyler"}, "age": {"16"}}),
+			body: "tyler|16|must be at least 18",
+		},
+		"conversion errors": {
+			action: func(wr http.ResponseWriter, req *http.Request) error {
+				_, err := torque.DecodeForm[MockProfileForm](req)
+				return torque.ReloadWithError(err)
+			},
+			req:  createMockFormRequest(http.MethodPost, url.Values{"name": {"tyler"}, "age": {"old"}}),
+			body: "tyler|0|must be a valid int",
+		},
+		"parsed form": {
+			action: func(wr http.ResponseWriter, req *http.Request) error {
+				if err := req.ParseForm(); err != nil {
+					return err
+				}
+				return torque.ReloadWithError(errors.New("failed to save profile"))
+			},
+			req:  createMockFormRequest(http.MethodPost, url.Values{"name": {"tyler"}, "age": {"30"}}),
+			body: "tyler|30|",
+		},
+		"not submitted": {
+			req:  httptest.NewRequest(http.MethodGet, "/", nil),
+			body: "|0|",
+		},
+	} {
+		h := torque.MustNew[MockViewModel](&struct {
+			MockAction
+			MockLoader[MockViewModel]
+			MockRenderer[MockViewModel]
+		}{
+			MockAction: MockAction{ActionFunc: tc.action},
+			MockLoader: MockLoader[MockViewModel]{
+				LoadFunc: func(req *http.Request) (MockViewModel, error) {
+					form, errs := torque.UseFormState[MockProfileForm](req)
+					return MockViewModel{Message: fmt.Sprintf("%s|%d|%s", form.Name, form.Age, errs.First("age"))}, nil
+				},
+			},
+			MockRenderer: MockRenderer[MockViewModel]{
+				RenderFunc: func(wr http.ResponseWriter, req *http.Request, vm MockViewModel) error {
+					_, err := wr.Write([]byte(vm.Message))
+					return err
+				},
+			},
+		})
+
+		wr := httptest.NewRecorder()
+		h.ServeHTTP(wr, tc.req)
+		Expect(wr.Code).To(Equal(http.StatusOK), name)
+		Expect(wr.Body.String()).To(Equal(tc.body), name)
+	}
+}